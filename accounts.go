@@ -0,0 +1,191 @@
+package spotifyclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrAccountNotFound is returned for user IDs an AccountManager doesn't know.
+var ErrAccountNotFound = errors.New("spotify: account not found")
+
+// AccountManager keeps tokens and clients for several Spotify accounts,
+// keyed by Spotify user ID. Tokens are refreshed and persisted like those of
+// a client created with WithRefreshableToken and WithTokenStore. It is safe
+// for concurrent use.
+type AccountManager struct {
+	clientID     string
+	clientSecret string
+	opts         []Option
+
+	// StoreFor returns the store for an account's token. When nil, tokens
+	// are only kept in memory.
+	StoreFor func(userID string) TokenStore
+
+	mu       sync.Mutex
+	accounts map[string]*account
+	current  string
+}
+
+type account struct {
+	store  TokenStore
+	client *Client
+}
+
+// NewAccountManager returns an AccountManager refreshing tokens for the given
+// application. An empty clientSecret refreshes with the PKCE flow. opts are
+// applied to every client it builds.
+func NewAccountManager(clientID, clientSecret string, opts ...Option) *AccountManager {
+	return &AccountManager{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		opts:         opts,
+		accounts:     make(map[string]*account),
+	}
+}
+
+// Add registers the account tok belongs to, e.g. the result of Login, and
+// returns its user ID. The first account added becomes the current one.
+func (m *AccountManager) Add(ctx context.Context, tok *Token) (string, error) {
+	client := m.newClient(tok, nil)
+	me, err := client.User.Me(ctx)
+	if err != nil {
+		return "", fmt.Errorf("spotify: resolve account: %w", err)
+	}
+
+	store := m.storeFor(me.ID)
+	ts := client.http.tokens.(*RefreshingTokenSource)
+	if err := ts.attachStore(store); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.accounts[me.ID] = &account{store: store, client: client}
+	if m.current == "" {
+		m.current = me.ID
+	}
+	return me.ID, nil
+}
+
+// Restore registers an account from the token in its store, without logging
+// in again. The client is built the first time it is needed.
+func (m *AccountManager) Restore(userID string) error {
+	store := m.storeFor(userID)
+	if _, err := store.Load(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accounts[userID]; !ok {
+		m.accounts[userID] = &account{store: store}
+	}
+	if m.current == "" {
+		m.current = userID
+	}
+	return nil
+}
+
+// Client returns the client for the given account.
+func (m *AccountManager) Client(userID string) (*Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.clientLocked(userID)
+}
+
+// Current returns the client for the current account.
+func (m *AccountManager) Current() (*Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.clientLocked(m.current)
+}
+
+// CurrentID returns the user ID of the current account, or "" if there is
+// none.
+func (m *AccountManager) CurrentID() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.current
+}
+
+// Switch makes the given account the current one.
+func (m *AccountManager) Switch(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accounts[userID]; !ok {
+		return fmt.Errorf("%w: %q", ErrAccountNotFound, userID)
+	}
+	m.current = userID
+	return nil
+}
+
+// List returns the user IDs of every account, sorted.
+func (m *AccountManager) List() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.accounts))
+	for id := range m.accounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Remove forgets an account and deletes its stored token. If it was the
+// current account, there is no current account afterwards.
+func (m *AccountManager) Remove(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[userID]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrAccountNotFound, userID)
+	}
+	if err := acc.store.Delete(); err != nil {
+		return err
+	}
+
+	delete(m.accounts, userID)
+	if m.current == userID {
+		m.current = ""
+	}
+	return nil
+}
+
+func (m *AccountManager) clientLocked(userID string) (*Client, error) {
+	acc, ok := m.accounts[userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrAccountNotFound, userID)
+	}
+
+	if acc.client == nil {
+		acc.client = m.newClient(nil, acc.store)
+	}
+	return acc.client, nil
+}
+
+func (m *AccountManager) newClient(tok *Token, store TokenStore) *Client {
+	opts := append([]Option{}, m.opts...)
+	opts = append(opts,
+		WithTokenStore(store),
+		WithRefreshableToken(tok, m.clientID, m.clientSecret),
+	)
+	return NewClient("", opts...)
+}
+
+func (m *AccountManager) storeFor(userID string) TokenStore {
+	if m.StoreFor == nil {
+		return &MemoryTokenStore{}
+	}
+	return m.StoreFor(userID)
+}
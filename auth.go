@@ -1,25 +1,20 @@
 package spotifyclient
 
 import (
+	"context"
+	secure "crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"io"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
-const (
-	accountsBaseURL                = "https://accounts.spotify.com"
-	ScopePlaylistModifyPublic      = "playlist-modify-public"
-	ScopePlaylistModifyPrivate     = "playlist-modify-private"
-	ScopePlaylistReadPrivate       = "playlist-read-private"
-	ScopePlaylistReadCollaborative = "playlist-read-collaborative"
-	ScopeUserReadPlaybackState     = "user-read-playback-state"
-	ScopeUserModifyPlaybackState   = "user-modify-playback-state"
-)
+const accountsBaseURL = "https://accounts.spotify.com"
 
 // Token represents an OAuth2 token.
 type Token struct {
@@ -28,6 +23,31 @@ type Token struct {
 	Scope        string `json:"scope"`
 	ExpiresIn    int    `json:"expires_in"`
 	RefreshToken string `json:"refresh_token"`
+	// Expiry is computed from ExpiresIn when the token is issued.
+	Expiry time.Time `json:"expiry,omitempty"`
+}
+
+// Valid reports whether the token has an access token that hasn't expired.
+func (t *Token) Valid() bool {
+	return t != nil && t.AccessToken != "" && !t.expiresWithin(0)
+}
+
+// expiresWithin reports whether the token expires within d. Tokens without
+// a known expiry never expire.
+func (t *Token) expiresWithin(d time.Duration) bool {
+	if t.Expiry.IsZero() {
+		return false
+	}
+	return time.Now().Add(d).After(t.Expiry)
+}
+
+// authorization returns the value of the Authorization header for t.
+func (t *Token) authorization() string {
+	tokenType := t.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + t.AccessToken
 }
 
 // CreatePKCEVerifierAndChallenge creates a PKCE verifier and challenge.
@@ -42,10 +62,10 @@ func CreatePKCEVerifierAndChallenge() (string, string, error) {
 	return string(verifier), challenge, nil
 }
 
-// GenerateRandomState generates a random state string.
+// GenerateRandomState generates a random state string using crypto/rand.
 func GenerateRandomState() (string, error) {
-	buf := make([]byte, 7)
-	_, err := rand.Read(buf)
+	buf := make([]byte, 16)
+	_, err := secure.Read(buf)
 	if err != nil {
 		return "", err
 	}
@@ -55,19 +75,19 @@ func GenerateRandomState() (string, error) {
 }
 
 // BuildAuthURI builds a authorization URI.
-func BuildAuthURI(clientID, redirectURI, state string, scopes ...string) string {
+func BuildAuthURI(clientID, redirectURI, state string, scopes ...Scope) string {
 	q := url.Values{}
 	q.Add("client_id", clientID)
 	q.Add("response_type", "code")
 	q.Add("redirect_uri", redirectURI)
 	q.Add("state", state)
-	q.Add("scope", strings.Join(scopes, " "))
+	q.Add("scope", NewScopeSet(scopes...).String())
 
 	return accountsBaseURL + "/authorize?" + q.Encode()
 }
 
 // BuildPKCEAuthURI builds a PKCE authorization URI.
-func BuildPKCEAuthURI(clientID, redirectURI, challenge, state string, scopes ...string) string {
+func BuildPKCEAuthURI(clientID, redirectURI, challenge, state string, scopes ...Scope) string {
 	q := url.Values{}
 	q.Add("client_id", clientID)
 	q.Add("response_type", "code")
@@ -75,12 +95,13 @@ func BuildPKCEAuthURI(clientID, redirectURI, challenge, state string, scopes ...
 	q.Add("code_challenge_method", "S256")
 	q.Add("code_challenge", challenge)
 	q.Add("state", state)
-	q.Add("scope", strings.Join(scopes, " "))
+	q.Add("scope", NewScopeSet(scopes...).String())
 
 	return accountsBaseURL + "/authorize?" + q.Encode()
 }
 
-func RequestToken(clientID, clientSecret, code, redirectURI string) (*Token, error) {
+// RequestToken requests a token using the authorization code flow.
+func RequestToken(ctx context.Context, clientID, clientSecret, code, redirectURI string) (*Token, error) {
 	query := make(url.Values)
 	query.Set("client_id", clientID)
 	query.Set("grant_type", "authorization_code")
@@ -88,12 +109,13 @@ func RequestToken(clientID, clientSecret, code, redirectURI string) (*Token, err
 	query.Set("redirect_uri", redirectURI)
 	body := strings.NewReader(query.Encode())
 
-	return postToken(body, clientID, clientSecret)
+	return postToken(ctx, http.DefaultClient, accountsBaseURL, body, clientID, clientSecret)
 
 }
 
-// RequestPKCEToken requests a token using the PKCE flow.
-func RequestPKCEToken(clientID, clientSecret, code, redirectURI, verifier string) (*Token, error) {
+// RequestPKCEToken requests a token using the PKCE flow. PKCE clients are
+// public, so no client secret is sent.
+func RequestPKCEToken(ctx context.Context, clientID, code, redirectURI, verifier string) (*Token, error) {
 	query := make(url.Values)
 	query.Set("client_id", clientID)
 	query.Set("grant_type", "authorization_code")
@@ -102,36 +124,76 @@ func RequestPKCEToken(clientID, clientSecret, code, redirectURI, verifier string
 	query.Set("code_verifier", verifier)
 	body := strings.NewReader(query.Encode())
 
-	return postToken(body, clientID, clientSecret)
+	return postToken(ctx, http.DefaultClient, accountsBaseURL, body, clientID, "")
+}
+
+// RefreshPKCEToken refreshes a token obtained with the PKCE flow without
+// sending a client secret.
+func RefreshPKCEToken(ctx context.Context, refreshToken, clientID string) (*Token, error) {
+	return requestRefresh(ctx, http.DefaultClient, accountsBaseURL, refreshToken, clientID, "")
+}
+
+// RefreshToken refreshes a token obtained with the authorization code flow.
+func RefreshToken(ctx context.Context, refreshToken, clientID, clientSecret string) (*Token, error) {
+	return requestRefresh(ctx, http.DefaultClient, accountsBaseURL, refreshToken, clientID, clientSecret)
 }
 
-// RefreshPKCEToken refreshes a token using the PKCE flow.
-func RefreshPKCEToken(refreshToken, clientID, clientSecret string) (*Token, error) {
+func requestRefresh(ctx context.Context, client *http.Client, accountsURL, refreshToken, clientID, clientSecret string) (*Token, error) {
 	query := make(url.Values)
 	query.Set("grant_type", "refresh_token")
 	query.Set("refresh_token", refreshToken)
 	query.Set("client_id", clientID)
 	body := strings.NewReader(query.Encode())
 
-	return postToken(body, clientID, clientSecret)
+	return postToken(ctx, client, accountsURL, body, clientID, clientSecret)
+}
 
+// RequestClientCredentialsToken requests an app-only token using the client
+// credentials flow. The token can't access user data.
+func RequestClientCredentialsToken(ctx context.Context, clientID, clientSecret string) (*Token, error) {
+	return requestClientCredentials(ctx, http.DefaultClient, accountsBaseURL, clientID, clientSecret)
 }
 
-func postToken(body io.Reader, clientID, clientSecret string) (*Token, error) {
-	req, err := http.NewRequest("POST", accountsBaseURL+"/api/token", body)
+func requestClientCredentials(ctx context.Context, client *http.Client, accountsURL, clientID, clientSecret string) (*Token, error) {
+	query := make(url.Values)
+	query.Set("grant_type", "client_credentials")
+	body := strings.NewReader(query.Encode())
+
+	return postToken(ctx, client, accountsURL, body, clientID, clientSecret)
+}
+
+// postToken sends a request to the token endpoint. Confidential clients
+// authenticate with HTTP Basic; public clients pass an empty clientSecret and
+// identify themselves with the client_id in the form body.
+func postToken(ctx context.Context, client *http.Client, accountsURL string, body io.Reader, clientID, clientSecret string) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", accountsURL+"/api/token", body)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(clientID+":"+clientSecret)))
-	res, err := http.DefaultClient.Do(req)
+	if clientSecret != "" {
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(clientID+":"+clientSecret)))
+	}
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode != http.StatusOK {
+		return nil, newOAuthError(res)
+	}
+
 	token := new(Token)
-	err = json.NewDecoder(res.Body).Decode(token)
+	if err := json.NewDecoder(res.Body).Decode(token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("spotify: token response has no access token")
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
 
-	return token, err
+	return token, nil
 }
@@ -1,99 +1,353 @@
 package spotifyclient
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
 )
 
-// Default Spotify API endpoint and scheme.
-const (
-	defaultHost   = "api.spotify.com"
-	defaultScheme = "https"
-)
+// Default Spotify API endpoint.
+const defaultBaseURL = "https://api.spotify.com"
+
+// jsonContentType is the content type of request bodies unless stated otherwise.
+const jsonContentType = "application/json"
 
 type service struct {
 	client *httpClient
 }
 
-// hyypConfig provides access to the Spotify Web API.
+// httpClient sends requests to the Spotify Web API.
 type httpClient struct {
-	Host   string
-	Scheme string
-	token  string
+	baseURL     string
+	accountsURL string
+	userAgent   string
+	client      *http.Client
+	tokens      TokenSource
+	store       TokenStore
+	// appOnly is set when requests are authorized without a user.
+	appOnly bool
+
+	// newTokens builds the token source once every option has been applied.
+	newTokens func(h *httpClient) TokenSource
+
+	rateLimit RateLimitPolicy
+	retry     RetryPolicy
+	limiter   rateLimiter
+}
+
+// Option configures a Client.
+type Option func(*httpClient)
+
+// WithBaseURL sets the Web API base URL, e.g. the URL of an httptest.Server.
+func WithBaseURL(baseURL string) Option {
+	return func(h *httpClient) {
+		h.baseURL = baseURL
+	}
+}
+
+// WithAccountsURL sets the base URL of the accounts service used to obtain
+// and refresh tokens.
+func WithAccountsURL(accountsURL string) Option {
+	return func(h *httpClient) {
+		h.accountsURL = accountsURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for every request. Use it to
+// configure transports, proxies and timeouts. A nil client uses
+// http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(h *httpClient) {
+		if client == nil {
+			client = http.DefaultClient
+		}
+		h.client = client
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(h *httpClient) {
+		h.userAgent = userAgent
+	}
+}
+
+// WithTokenSource authorizes requests with tokens from ts instead of the
+// token passed to NewClient. A ClientCredentialsTokenSource makes the client
+// app-only, as with WithClientCredentials, and requests its tokens with the
+// client's HTTP client and accounts URL. A nil ts is ignored.
+func WithTokenSource(ts TokenSource) Option {
+	return func(h *httpClient) {
+		if ts == nil {
+			return
+		}
+		h.tokens = ts
+		h.newTokens = nil
+		h.appOnly = false
+		if cc, ok := ts.(*ClientCredentialsTokenSource); ok {
+			h.newTokens = func(h *httpClient) TokenSource {
+				cc.use(h.client, h.accountsURL)
+				return cc
+			}
+			h.appOnly = true
+		}
+	}
+}
+
+// WithRefreshableToken authorizes requests with tok and refreshes it through
+// the accounts service before it expires. An empty clientSecret refreshes
+// with the PKCE flow. A nil tok is loaded from the store set with
+// WithTokenStore.
+func WithRefreshableToken(tok *Token, clientID, clientSecret string) Option {
+	return func(h *httpClient) {
+		h.newTokens = func(h *httpClient) TokenSource {
+			ts := NewRefreshingTokenSource(tok, func(ctx context.Context, refreshToken string) (*Token, error) {
+				return requestRefresh(ctx, h.client, h.accountsURL, refreshToken, clientID, clientSecret)
+			})
+			ts.Store = h.store
+			return ts
+		}
+		h.appOnly = false
+	}
+}
+
+// WithClientCredentials authorizes requests with app-only tokens obtained
+// through the client credentials flow. Such clients can access the catalog
+// but not user data; user-only endpoints fail with ErrUserRequired.
+func WithClientCredentials(clientID, clientSecret string) Option {
+	return func(h *httpClient) {
+		h.newTokens = func(h *httpClient) TokenSource {
+			return newClientCredentialsTokenSource(func(ctx context.Context) (*Token, error) {
+				return requestClientCredentials(ctx, h.client, h.accountsURL, clientID, clientSecret)
+			})
+		}
+		h.appOnly = true
+	}
+}
+
+// WithTokenStore persists the tokens refreshed by WithRefreshableToken.
+func WithTokenStore(store TokenStore) Option {
+	return func(h *httpClient) {
+		h.store = store
+	}
+}
+
+// WithRetryPolicy sets how the client retries transient failures.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(h *httpClient) {
+		h.retry = policy
+	}
+}
+
+// WithRateLimitPolicy sets how the client handles 429 Too Many Requests.
+func WithRateLimitPolicy(policy RateLimitPolicy) Option {
+	return func(h *httpClient) {
+		h.rateLimit = policy
+	}
 }
 
 // Client provides access to the Spotify Web API.
 type Client struct {
 	User     *UserService
 	Playlist *PlaylistService
+	Search   *SearchService
+
+	http *httpClient
 }
 
-// NewClient creates a new Spotify Web API client.
-func NewClient(token string, client *httpClient) *Client {
-	if client == nil || client.Host == "" || client.Scheme == "" {
-		client = &httpClient{
-			Host:   defaultHost,
-			Scheme: defaultScheme,
-		}
+// NewClient creates a new Spotify Web API client authorized with the given
+// access token. Pass WithTokenSource or WithRefreshableToken to use tokens
+// that are refreshed automatically instead.
+func NewClient(token string, opts ...Option) *Client {
+	client := &httpClient{
+		baseURL:     defaultBaseURL,
+		accountsURL: accountsBaseURL,
+		client:      http.DefaultClient,
+		tokens:      StaticTokenSource(&Token{AccessToken: token}),
+		rateLimit:   DefaultRateLimitPolicy,
+		retry:       DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	if client.newTokens != nil {
+		client.tokens = client.newTokens(client)
 	}
-	client.token = token
 
 	return &Client{
 		User:     &UserService{client: client},
 		Playlist: &PlaylistService{client: client},
+		Search:   &SearchService{client: client},
+		http:     client,
 	}
 }
 
+// requireUser fails when the client has no user to act on behalf of.
+func (h *httpClient) requireUser() error {
+	if h.appOnly {
+		return ErrUserRequired
+	}
+	return nil
+}
+
 // Get sends a GET request to the Spotify Web API.
-func (h *httpClient) get(apiVersion, endpoint string, query url.Values, res interface{}) error {
-	return h.do(http.MethodGet, apiVersion, endpoint, query, nil, res)
+func (h *httpClient) get(ctx context.Context, apiVersion, endpoint string, query url.Values, res interface{}) error {
+	return h.do(ctx, http.MethodGet, apiVersion, endpoint, query, jsonContentType, nil, true, res)
 }
 
 // Post sends a POST request to the Spotify Web API.
-func (h *httpClient) post(apiVersion, endpoint string, query url.Values, body io.Reader, res interface{}) error {
-	return h.do(http.MethodPost, apiVersion, endpoint, query, body, res)
+func (h *httpClient) post(ctx context.Context, apiVersion, endpoint string, query url.Values, body io.Reader, res interface{}) error {
+	return h.do(ctx, http.MethodPost, apiVersion, endpoint, query, jsonContentType, body, false, res)
 }
 
 // Put sends a PUT request to the Spotify Web API.
-func (h *httpClient) put(apiVersion, endpoint string, query url.Values, body io.Reader) error {
-	return h.do(http.MethodPut, apiVersion, endpoint, query, body, nil)
+func (h *httpClient) put(ctx context.Context, apiVersion, endpoint string, query url.Values, body io.Reader, res interface{}) error {
+	return h.do(ctx, http.MethodPut, apiVersion, endpoint, query, jsonContentType, body, true, res)
+}
+
+// putOnce sends a PUT request that is never retried, for updates that can't
+// safely be applied twice.
+func (h *httpClient) putOnce(ctx context.Context, apiVersion, endpoint string, query url.Values, body io.Reader, res interface{}) error {
+	return h.do(ctx, http.MethodPut, apiVersion, endpoint, query, jsonContentType, body, false, res)
+}
+
+// putContent sends a PUT request with a body of the given content type.
+func (h *httpClient) putContent(ctx context.Context, apiVersion, endpoint string, query url.Values, contentType string, body io.Reader, res interface{}) error {
+	return h.do(ctx, http.MethodPut, apiVersion, endpoint, query, contentType, body, true, res)
 }
 
 // Delete sends a DELETE request to the Spotify Web API.
-func (h *httpClient) delete(apiVersion, endpoint string, query url.Values) error {
-	return h.do(http.MethodDelete, apiVersion, endpoint, query, nil, nil)
+func (h *httpClient) delete(ctx context.Context, apiVersion, endpoint string, query url.Values, body io.Reader, res interface{}) error {
+	return h.do(ctx, http.MethodDelete, apiVersion, endpoint, query, jsonContentType, body, true, res)
 }
 
-func (h *httpClient) do(method, apiVersion, endpoint string, query url.Values, body io.Reader, result interface{}) error {
-	url := url.URL{
-		Host:     h.Host,
-		Path:     path.Join(apiVersion, endpoint),
-		RawQuery: query.Encode(),
-		Scheme:   h.Scheme,
-	}
+// deleteOnce sends a DELETE request that is never retried, for removals that
+// can't safely be applied twice.
+func (h *httpClient) deleteOnce(ctx context.Context, apiVersion, endpoint string, query url.Values, body io.Reader, res interface{}) error {
+	return h.do(ctx, http.MethodDelete, apiVersion, endpoint, query, jsonContentType, body, false, res)
+}
 
-	req, err := http.NewRequest(method, url.String(), body)
+// do sends a request, retrying it as the policies allow. Requests that aren't
+// idempotent are only resent when they were rejected without being applied,
+// i.e. on 401 and 429 responses.
+func (h *httpClient) do(ctx context.Context, method, apiVersion, endpoint string, query url.Values, contentType string, body io.Reader, idempotent bool, result interface{}) error {
+	url, err := url.Parse(h.baseURL)
 	if err != nil {
 		return err
 	}
+	url.Path = path.Join("/", url.Path, apiVersion, endpoint)
+	url.RawQuery = query.Encode()
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", h.token))
+	// Buffer the body so it can be sent again when a request is retried.
+	var payload []byte
+	if body != nil {
+		if payload, err = io.ReadAll(body); err != nil {
+			return err
+		}
+	}
 
-	client := http.Client{}
-	res, err := client.Do(req)
+	attempts, rateLimited, reauthorized := 0, 0, false
+	for {
+		if err := h.limiter.wait(ctx); err != nil {
+			return err
+		}
+
+		token, err := h.tokens.Token(ctx)
+		if err != nil {
+			return err
+		}
+
+		attempts++
+		res, err := h.send(ctx, token, method, url.String(), contentType, payload)
+		if err != nil {
+			if isTransient(err) && h.retry.canRetry(method, idempotent, attempts) {
+				if err := sleep(ctx, h.retry.backoff(attempts)); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		// A token can be revoked or expire early; refresh it once and retry.
+		if res.StatusCode == http.StatusUnauthorized && !reauthorized {
+			if r, ok := h.tokens.(staleRefresher); ok {
+				drain(res)
+				if err := r.refreshStale(ctx, token.AccessToken); err != nil {
+					return err
+				}
+				attempts--
+				reauthorized = true
+				continue
+			}
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests && rateLimited < h.rateLimit.MaxRetries {
+			wait, ok := retryAfter(res.Header)
+			if !ok {
+				wait = h.rateLimit.DefaultWait
+			}
+			if h.rateLimit.MaxWait == 0 || wait <= h.rateLimit.MaxWait {
+				drain(res)
+				h.limiter.block(wait)
+				// Rate-limited attempts don't count against the retry policy.
+				attempts--
+				rateLimited++
+				continue
+			}
+			// The wait is too long for this request, but later ones
+			// still have to honour it.
+			h.limiter.block(wait)
+		}
+
+		if h.retry.retryStatus(res.StatusCode) && h.retry.canRetry(method, idempotent, attempts) {
+			wait, ok := retryAfter(res.Header)
+			if !ok || wait > h.retry.MaxBackoff {
+				wait = h.retry.backoff(attempts)
+			}
+			drain(res)
+			if err := sleep(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+
+		return decodeResponse(res, result)
+	}
+}
+
+func (h *httpClient) send(ctx context.Context, token *Token, method, url, contentType string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", token.authorization())
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if h.userAgent != "" {
+		req.Header.Set("User-Agent", h.userAgent)
 	}
+
+	return h.client.Do(req)
+}
+
+func decodeResponse(res *http.Response, result interface{}) error {
 	defer res.Body.Close()
 
 	// Success
 	if res.StatusCode >= 200 && res.StatusCode < 300 {
-		if result != nil {
+		if result != nil && res.StatusCode != http.StatusNoContent {
 			if err := json.NewDecoder(res.Body).Decode(result); err != nil {
 				return err
 			}
@@ -101,13 +355,11 @@ func (h *httpClient) do(method, apiVersion, endpoint string, query url.Values, b
 		return nil
 	}
 
-	// Error
-	spotifyErr := &struct {
-		Error HttpError `json:"error"`
-	}{}
-	if err := json.NewDecoder(res.Body).Decode(spotifyErr); err != nil {
-		return err
-	}
+	return newAPIError(res)
+}
 
-	return errors.New(spotifyErr.Error.Message)
+// drain discards the rest of the body so the connection can be reused.
+func drain(res *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(res.Body, maxErrorBody))
+	res.Body.Close()
 }
@@ -0,0 +1,209 @@
+package spotifyclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client sending its requests to handler, with short
+// waits so retries don't slow the tests down.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	opts = append([]Option{
+		WithBaseURL(srv.URL),
+		WithRateLimitPolicy(RateLimitPolicy{MaxRetries: 2, MaxWait: time.Second, DefaultWait: 10 * time.Millisecond}),
+		WithRetryPolicy(RetryPolicy{
+			MaxAttempts: 3,
+			MinBackoff:  time.Millisecond,
+			MaxBackoff:  5 * time.Millisecond,
+			StatusCodes: DefaultRetryPolicy.StatusCodes,
+			Methods:     DefaultRetryPolicy.Methods,
+		}),
+	}, opts...)
+	return NewClient("token", opts...)
+}
+
+// failFirst answers the first n requests with status and the others with
+// body, counting every request in calls.
+func failFirst(n int, status int, header http.Header, body string, calls *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(calls, 1) <= int32(n) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		header    http.Header
+		method    string
+		wantErr   bool
+		wantCalls int32
+	}{
+		{name: "default wait", failures: 1, method: http.MethodGet, wantCalls: 2},
+		{name: "retry after", failures: 2, header: http.Header{"Retry-After": {"0"}}, method: http.MethodGet, wantCalls: 3},
+		{name: "post is retried", failures: 1, method: http.MethodPost, wantCalls: 2},
+		{name: "too many", failures: 3, method: http.MethodGet, wantErr: true, wantCalls: 3},
+		{name: "wait too long", failures: 1, header: http.Header{"Retry-After": {"60"}}, method: http.MethodGet, wantErr: true, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			c := newTestClient(t, failFirst(tt.failures, http.StatusTooManyRequests, tt.header, `{}`, &calls))
+
+			err := c.http.do(context.Background(), tt.method, "v1", "/me", nil, jsonContentType, strings.NewReader("{}"), tt.method != http.MethodPost, nil)
+			if tt.wantErr {
+				if !IsRateLimited(err) {
+					t.Errorf("err = %v, want a rate limit error", err)
+				}
+			} else if err != nil {
+				t.Errorf("err = %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("%d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRateLimitHoldsBackOtherRequests(t *testing.T) {
+	var calls int32
+	var first time.Time
+	limited := make(chan struct{})
+	times := make(chan time.Time, 3)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			first = time.Now()
+			w.WriteHeader(http.StatusTooManyRequests)
+			close(limited)
+			return
+		}
+		times <- time.Now()
+		w.Write([]byte(`{}`))
+	}, WithRateLimitPolicy(RateLimitPolicy{MaxRetries: 1, DefaultWait: 100 * time.Millisecond}))
+
+	errs := make(chan error, 2)
+	go func() {
+		errs <- c.http.get(context.Background(), "v1", "/me", nil, nil)
+	}()
+	<-limited
+	// Give the first request time to see its 429 before sending another.
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		errs <- c.http.get(context.Background(), "v1", "/me", nil, nil)
+	}()
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+		if d := (<-times).Sub(first); d < 90*time.Millisecond {
+			t.Errorf("request sent %v after the 429, want it held back for 100ms", d)
+		}
+	}
+}
+
+func TestWithTokenSourceAppOnly(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, failFirst(0, 0, nil, `{"id":"user"}`, &calls),
+		WithTokenSource(NewClientCredentialsTokenSource("id", "secret")))
+
+	if _, err := c.User.Me(context.Background()); !errors.Is(err, ErrUserRequired) {
+		t.Errorf("err = %v, want ErrUserRequired", err)
+	}
+	if calls != 0 {
+		t.Errorf("%d requests, want none", calls)
+	}
+}
+
+// countingTransport counts the requests sent through it.
+type countingTransport struct {
+	calls int32
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.calls, 1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestWithTokenSourceClientCredentials(t *testing.T) {
+	accounts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); r.URL.Path != "/api/token" || id != "id" || secret != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"app","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer accounts.Close()
+
+	transport := new(countingTransport)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer app" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	},
+		// The source picks up options given after it.
+		WithTokenSource(NewClientCredentialsTokenSource("id", "secret")),
+		WithAccountsURL(accounts.URL),
+		WithHTTPClient(&http.Client{Transport: transport}))
+
+	if err := c.http.get(context.Background(), "v1", "/tracks/t", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if transport.calls != 2 {
+		t.Errorf("%d requests through the client's HTTP client, want 2", transport.calls)
+	}
+}
+
+func TestRateLimitWaitTooLongHoldsBackOtherRequests(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, failFirst(1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}}, `{}`, &calls),
+		WithRateLimitPolicy(RateLimitPolicy{MaxRetries: 1, MaxWait: 10 * time.Millisecond}))
+
+	if err := c.http.get(context.Background(), "v1", "/me", nil, nil); !IsRateLimited(err) {
+		t.Fatalf("err = %v, want a rate limit error", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.http.get(ctx, "v1", "/me", nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the request held back past its deadline", err)
+	}
+	if calls != 1 {
+		t.Errorf("%d requests, want 1", calls)
+	}
+}
+
+func TestNilOptions(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, failFirst(0, 0, nil, `{"id":"user"}`, &calls),
+		WithHTTPClient(nil), WithTokenSource(nil))
+
+	if _, err := c.User.Me(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("%d requests, want 1", calls)
+	}
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
-	"net/http"
+	"flag"
+	"os"
+	"path/filepath"
+	"time"
 
 	spotify "github.com/josuerosadeavila/spotify-client"
 )
@@ -15,87 +17,65 @@ const (
 	RedirectURI  = "http://localhost:1024/callback"
 )
 
-func Login() string {
-	state, err := spotify.GenerateRandomState()
-	if err != nil {
-		panic(err)
+func Login(ctx context.Context, headless bool) *spotify.Token {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	cfg := spotify.LoginConfig{
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		RedirectURI:  RedirectURI,
+		Scopes: []spotify.Scope{
+			spotify.ScopePlaylistReadPrivate,
+			spotify.ScopePlaylistReadCollaborative,
+			spotify.ScopePlaylistModifyPublic,
+			spotify.ScopePlaylistModifyPrivate,
+			spotify.ScopeUserReadPlaybackState,
+			spotify.ScopeUserModifyPlaybackState,
+		},
 	}
 
-	scopes := []string{
-		spotify.ScopePlaylistReadPrivate,
-		spotify.ScopePlaylistReadCollaborative,
-		spotify.ScopePlaylistModifyPublic,
-		spotify.ScopePlaylistModifyPublic,
-		spotify.ScopePlaylistModifyPrivate,
-		spotify.ScopeUserReadPlaybackState,
-		spotify.ScopeUserModifyPlaybackState,
+	var token *spotify.Token
+	var err error
+	if headless {
+		token, err = spotify.LoginHeadless(ctx, cfg, os.Stdin, os.Stdout)
+	} else {
+		token, err = spotify.Login(ctx, cfg)
 	}
-	uri := spotify.BuildAuthURI(ClientID, RedirectURI, state, scopes...)
-
-	// if err := browser.OpenURL(uri); err != nil {
-	// 	panic(err)
-	// }
-
-	req, err := http.NewRequest("GET", uri, nil)
 	if err != nil {
 		panic(err)
 	}
-	client := new(http.Client)
-	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-		http.Get(RedirectURI)
-		return nil
-	}
 
-	response, err := client.Do(req)
-	if err == nil {
-		if response.StatusCode == http.StatusFound { //status code 302
-			fmt.Println(response.Location())
-		}
-	}
+	return token
+}
 
-	code, err := ListenForCode(state)
-	if err != nil {
-		panic(err)
-	}
+func main() {
+	headless := flag.Bool("headless", false, "log in by pasting the redirect URL instead of opening a browser")
+	flag.Parse()
+
+	ctx := context.Background()
 
-	token, err := spotify.RequestToken(ClientID, ClientSecret, code, RedirectURI)
+	dir, err := os.UserConfigDir()
 	if err != nil {
 		panic(err)
 	}
+	store := &spotify.FileTokenStore{Path: filepath.Join(dir, "spotify-client", "token.json")}
 
-	return token.AccessToken
-}
-
-func ListenForCode(state string) (code string, err error) {
-	server := &http.Server{Addr: ":1024"}
-
-	http.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Query().Get("state") != state || r.URL.Query().Get("error") != "" {
-			err = errors.New("authorization failed")
-			fmt.Fprintln(w, "Failure.")
-		} else {
-			code = r.URL.Query().Get("code")
-			fmt.Fprintln(w, "Success!")
+	// Only go through the browser when there is no saved token yet.
+	if _, err := store.Load(); errors.Is(err, spotify.ErrTokenNotFound) {
+		if err := store.Save(Login(ctx, *headless)); err != nil {
+			panic(err)
 		}
-
-		// Use a separate thread so browser doesn't show a "No Connection" message
-		go func() {
-			server.Shutdown(context.Background())
-		}()
-	})
-
-	if err := server.ListenAndServe(); err != http.ErrServerClosed {
-		return "", err
+	} else if err != nil {
+		panic(err)
 	}
 
-	return
-}
-
-func main() {
-	token := Login()
-	api := spotify.NewClient(token, nil)
+	api := spotify.NewClient("",
+		spotify.WithTokenStore(store),
+		spotify.WithRefreshableToken(nil, ClientID, ClientSecret),
+	)
 
-	d, err := api.User.Devices()
+	d, err := api.User.Devices(ctx)
 	if err != nil {
 		panic(err)
 	}
@@ -110,7 +90,7 @@ func main() {
 		},
 	}
 
-	err = api.User.Play(d.Devices[0].ID, body)
+	err = api.User.Play(ctx, d.Devices[0].ID, body)
 	if err != nil {
 		panic(err)
 	}
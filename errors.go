@@ -0,0 +1,185 @@
+package spotifyclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Player error reasons reported by the Spotify Web API.
+// https://developer.spotify.com/documentation/web-api/reference/#object-playererrorreasons
+const (
+	ReasonNoPrevTrack           = "NO_PREV_TRACK"
+	ReasonNoNextTrack           = "NO_NEXT_TRACK"
+	ReasonNoSpecificTrack       = "NO_SPECIFIC_TRACK"
+	ReasonAlreadyPaused         = "ALREADY_PAUSED"
+	ReasonNotPaused             = "NOT_PAUSED"
+	ReasonNotPlayingLocally     = "NOT_PLAYING_LOCALLY"
+	ReasonNotPlayingTrack       = "NOT_PLAYING_TRACK"
+	ReasonNotPlayingContext     = "NOT_PLAYING_CONTEXT"
+	ReasonEndlessContext        = "ENDLESS_CONTEXT"
+	ReasonContextDisallow       = "CONTEXT_DISALLOW"
+	ReasonAlreadyPlaying        = "ALREADY_PLAYING"
+	ReasonRateLimited           = "RATE_LIMITED"
+	ReasonRemoteControlDisallow = "REMOTE_CONTROL_DISALLOW"
+	ReasonDeviceNotControllable = "DEVICE_NOT_CONTROLLABLE"
+	ReasonVolumeControlDisallow = "VOLUME_CONTROL_DISALLOW"
+	ReasonNoActiveDevice        = "NO_ACTIVE_DEVICE"
+	ReasonPremiumRequired       = "PREMIUM_REQUIRED"
+	ReasonUnknown               = "UNKNOWN"
+)
+
+// ErrUserRequired is returned when a client authorized with the client
+// credentials flow calls an endpoint that acts on behalf of a user.
+var ErrUserRequired = errors.New("spotify: endpoint requires a user token, but the client is authorized with client credentials")
+
+// ErrLoginRequired matches errors that can only be resolved by the user
+// logging in again, such as a revoked or expired refresh token. Check for it
+// with errors.Is.
+var ErrLoginRequired = errors.New("spotify: login required")
+
+// OAuth error codes returned by the accounts service.
+// https://www.rfc-editor.org/rfc/rfc6749#section-5.2
+const (
+	OAuthInvalidRequest       = "invalid_request"
+	OAuthInvalidClient        = "invalid_client"
+	OAuthInvalidGrant         = "invalid_grant"
+	OAuthUnauthorizedClient   = "unauthorized_client"
+	OAuthUnsupportedGrantType = "unsupported_grant_type"
+	OAuthInvalidScope         = "invalid_scope"
+)
+
+// OAuthError is returned when the accounts service rejects a token request.
+type OAuthError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is the OAuth error code, e.g. OAuthInvalidGrant.
+	Code string `json:"error"`
+	// Description is the human readable error_description, if any.
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	msg := fmt.Sprintf("spotify: token request failed: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrLoginRequired) report true for rejected grants,
+// which is how the accounts service reports a revoked refresh token.
+func (e *OAuthError) Is(target error) bool {
+	return target == ErrLoginRequired && e.Code == OAuthInvalidGrant
+}
+
+// newOAuthError builds an OAuthError from a failed token response.
+func newOAuthError(res *http.Response) *OAuthError {
+	oauthErr := &OAuthError{StatusCode: res.StatusCode}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	if err != nil {
+		return oauthErr
+	}
+	if err := json.Unmarshal(data, oauthErr); err != nil || oauthErr.Code == "" {
+		oauthErr.Description = strings.TrimSpace(string(data))
+	}
+	return oauthErr
+}
+
+// maxErrorBody caps how much of a non-JSON error body is kept on an APIError.
+const maxErrorBody = 4 << 10
+
+// APIError is returned when the Spotify Web API responds with a non-2xx status.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Message is the error message reported by Spotify, if any.
+	Message string
+	// Reason is the player error reason, e.g. ReasonNoActiveDevice.
+	Reason string
+	// Method and Path identify the request that failed.
+	Method string
+	Path   string
+	// Header holds the response headers.
+	Header http.Header
+	// Body holds the raw response body when it was not a Spotify error object.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "spotify: %s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	if e.Reason != "" {
+		fmt.Fprintf(&b, " (%s)", e.Reason)
+	}
+	return b.String()
+}
+
+// newAPIError builds an APIError from a failed response. Bodies that are not
+// a Spotify error object (e.g. an HTML page from the edge) are kept verbatim.
+func newAPIError(res *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Method:     res.Request.Method,
+		Path:       res.Request.URL.Path,
+		Header:     res.Header,
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	if err != nil {
+		return apiErr
+	}
+
+	spotifyErr := &struct {
+		Error HttpError `json:"error"`
+	}{}
+	if err := json.Unmarshal(data, spotifyErr); err != nil || spotifyErr.Error.Message == "" {
+		apiErr.Body = data
+		return apiErr
+	}
+
+	apiErr.Message = spotifyErr.Error.Message
+	apiErr.Reason = spotifyErr.Error.Reason
+	return apiErr
+}
+
+func hasStatus(err error, code int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == code
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsRateLimited reports whether err is an APIError with status 429.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsUnauthorized reports whether err is an APIError with status 401.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is an APIError with status 403.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// HasReason reports whether err is an APIError carrying the given player reason.
+func HasReason(err error, reason string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Reason == reason
+}
@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVHeader is the header row of CSV exports.
+var CSVHeader = []string{"title", "artists", "album", "isrc", "duration_ms", "added_at", "added_by", "uri"}
+
+// csvArtistSeparator separates artist names in the artists column.
+const csvArtistSeparator = "; "
+
+// csvWriter writes one row per track under CSVHeader.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Begin(p *Playlist) error {
+	return c.w.Write(CSVHeader)
+}
+
+func (c *csvWriter) Track(t *Track) error {
+	addedAt := ""
+	if !t.AddedAt.IsZero() {
+		addedAt = t.AddedAt.UTC().Format(time.RFC3339)
+	}
+
+	return c.w.Write([]string{
+		t.Title,
+		strings.Join(t.Artists, csvArtistSeparator),
+		t.Album,
+		t.ISRC,
+		strconv.FormatInt(t.DurationMS, 10),
+		addedAt,
+		t.AddedBy,
+		t.URI,
+	})
+}
+
+func (c *csvWriter) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
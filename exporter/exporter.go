@@ -0,0 +1,169 @@
+// Package exporter writes Spotify playlists as M3U, CSV, JSON or XSPF.
+//
+// Tracks are written as they are fetched, so playlists of any size can be
+// exported without holding them in memory.
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	spotify "github.com/josuerosadeavila/spotify-client"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	M3U  Format = "m3u"
+	CSV  Format = "csv"
+	JSON Format = "json"
+	XSPF Format = "xspf"
+)
+
+// Writer writes a playlist in one format, one track at a time.
+type Writer interface {
+	// Begin writes the playlist header.
+	Begin(p *Playlist) error
+	// Track writes one track.
+	Track(t *Track) error
+	// End finishes the output.
+	End() error
+}
+
+// NewWriter returns a Writer for format writing to w.
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case M3U:
+		return newM3UWriter(w), nil
+	case CSV:
+		return newCSVWriter(w), nil
+	case JSON:
+		return newJSONWriter(w), nil
+	case XSPF:
+		return newXSPFWriter(w), nil
+	default:
+		return nil, fmt.Errorf("exporter: unknown format %q", format)
+	}
+}
+
+// Playlist is the exported description of a playlist.
+type Playlist struct {
+	ID          string `json:"id"`
+	URI         string `json:"uri"`
+	URL         string `json:"url,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Owner       string `json:"owner"`
+	SnapshotID  string `json:"snapshot_id"`
+}
+
+// Track is the exported description of a playlist item.
+type Track struct {
+	URI        string    `json:"uri"`
+	URL        string    `json:"url,omitempty"`
+	Title      string    `json:"title"`
+	Artists    []string  `json:"artists"`
+	Album      string    `json:"album,omitempty"`
+	ISRC       string    `json:"isrc,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	AddedAt    time.Time `json:"added_at"`
+	AddedBy    string    `json:"added_by,omitempty"`
+	IsLocal    bool      `json:"is_local,omitempty"`
+}
+
+// Location returns the best reference to the track for other players: its
+// Spotify URL, or its URI when there is none.
+func (t *Track) Location() string {
+	if t.URL != "" {
+		return t.URL
+	}
+	return t.URI
+}
+
+// Duration returns the track duration.
+func (t *Track) Duration() time.Duration {
+	return time.Duration(t.DurationMS) * time.Millisecond
+}
+
+// NewPlaylist converts a Spotify playlist.
+func NewPlaylist(p *spotify.Playlist) *Playlist {
+	return &Playlist{
+		ID:          p.ID,
+		URI:         p.URI,
+		URL:         p.ExternalURLs["spotify"],
+		Name:        p.Name,
+		Description: p.Description,
+		Owner:       p.Owner.ID,
+		SnapshotID:  p.SnapshotID,
+	}
+}
+
+// NewTrack converts a Spotify playlist item.
+func NewTrack(item *spotify.PlaylistTrack) *Track {
+	t := &item.Track
+	track := &Track{
+		URI:     t.URI,
+		URL:     t.ExternalURLs["spotify"],
+		Title:   t.Name,
+		Album:   t.Album.Name,
+		ISRC:    t.ExternalIDs["isrc"],
+		AddedAt: item.AddedAt,
+		AddedBy: item.AddedBy.ID,
+		IsLocal: item.IsLocal,
+		Artists: make([]string, 0, len(t.Artists)),
+	}
+	for _, a := range t.Artists {
+		track.Artists = append(track.Artists, a.Name)
+	}
+	if t.Duration != nil {
+		track.DurationMS = t.Duration.Milliseconds()
+	}
+	return track
+}
+
+// Fields requested by Export, limited to what NewPlaylist and NewTrack read
+// so large playlists don't download full track objects.
+const (
+	playlistFields = "id,uri,external_urls,name,description,owner(id),snapshot_id"
+	itemFields     = "items(added_at,added_by(id),is_local,track(uri,external_urls,name,album(name),artists(name),external_ids,duration_ms))"
+)
+
+// Export walks the playlist with the given ID and writes it to w.
+func Export(ctx context.Context, c *spotify.Client, id string, format Format, w io.Writer) error {
+	out, err := NewWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	playlist, err := c.Playlist.FetchFields(ctx, id, playlistFields)
+	if err != nil {
+		return err
+	}
+	if err := out.Begin(NewPlaylist(playlist)); err != nil {
+		return err
+	}
+
+	it := c.Playlist.Items(id, &spotify.PlaylistItemsOptions{Fields: itemFields})
+	for it.Next(ctx) {
+		// Unavailable tracks come back as null and can't be referred to.
+		if it.Item().Track.URI == "" {
+			continue
+		}
+		if err := out.Track(NewTrack(it.Item())); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	return out.End()
+}
+
+func joinArtists(artists []string) string {
+	return strings.Join(artists, ", ")
+}
@@ -0,0 +1,198 @@
+package exporter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	spotify "github.com/josuerosadeavila/spotify-client"
+	"github.com/josuerosadeavila/spotify-client/exporter"
+	"github.com/josuerosadeavila/spotify-client/importer"
+)
+
+var formats = []exporter.Format{exporter.M3U, exporter.CSV, exporter.JSON, exporter.XSPF}
+
+var testPlaylist = &exporter.Playlist{
+	ID:   "p",
+	URI:  "spotify:playlist:p",
+	Name: "Mix <1> & \"friends\"",
+}
+
+var testTracks = []*exporter.Track{
+	{
+		URI:        "spotify:track:1",
+		URL:        "https://open.spotify.com/track/1",
+		Title:      `Say "Hello", <World> & Co`,
+		Artists:    []string{"Tyler, The Creator", "Kali Uchis"},
+		Album:      "Flower Boy",
+		ISRC:       "USQX91700001",
+		DurationMS: 180400,
+		AddedAt:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	},
+	{
+		URI:        "spotify:track:2",
+		Title:      "Plain",
+		Artists:    []string{"Someone"},
+		DurationMS: 60000,
+	},
+}
+
+func write(t *testing.T, format exporter.Format, tracks []*exporter.Track) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := exporter.NewWriter(format, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Begin(testPlaylist); err != nil {
+		t.Fatal(err)
+	}
+	for _, track := range tracks {
+		if err := w.Track(track); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.End(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range formats {
+		t.Run(string(format), func(t *testing.T) {
+			out := write(t, format, testTracks)
+			entries, err := importer.Read(format, strings.NewReader(out))
+			if err != nil {
+				t.Fatalf("read back: %v\n%s", err, out)
+			}
+			if len(entries) != len(testTracks) {
+				t.Fatalf("read %d entries, want %d\n%s", len(entries), len(testTracks), out)
+			}
+
+			for i, e := range entries {
+				want := testTracks[i]
+				if e.URI != want.URI {
+					t.Errorf("entry %d: URI %q, want %q", i, e.URI, want.URI)
+				}
+				if e.Title != want.Title {
+					t.Errorf("entry %d: title %q, want %q", i, e.Title, want.Title)
+				}
+				// Only CSV and JSON keep artist names containing commas apart.
+				if format == exporter.CSV || format == exporter.JSON {
+					if !reflect.DeepEqual(e.Artists, want.Artists) {
+						t.Errorf("entry %d: artists %q, want %q", i, e.Artists, want.Artists)
+					}
+				} else if strings.Join(e.Artists, ", ") != strings.Join(want.Artists, ", ") {
+					t.Errorf("entry %d: artists %q, want %q", i, e.Artists, want.Artists)
+				}
+				// M3U has no ISRC and rounds durations to seconds.
+				if format != exporter.M3U && e.ISRC != want.ISRC {
+					t.Errorf("entry %d: ISRC %q, want %q", i, e.ISRC, want.ISRC)
+				}
+				if d := e.Duration - want.Duration(); d < -time.Second || d > time.Second {
+					t.Errorf("entry %d: duration %v, want %v", i, e.Duration, want.Duration())
+				}
+			}
+		})
+	}
+}
+
+func TestM3USingleLines(t *testing.T) {
+	out := write(t, exporter.M3U, []*exporter.Track{{
+		URI:     "spotify:track:1",
+		Title:   "Line\nBreak",
+		Artists: []string{"A\r\nB"},
+	}})
+
+	want := "#EXTM3U\n#PLAYLIST:Mix <1> & \"friends\"\n#EXTINF:-1,A  B - Line Break\nspotify:track:1\n"
+	if out != want {
+		t.Errorf("got\n%q\nwant\n%q", out, want)
+	}
+}
+
+func TestCSVHeader(t *testing.T) {
+	out := write(t, exporter.CSV, testTracks[:1])
+
+	header, _, _ := strings.Cut(out, "\n")
+	if header != strings.Join(exporter.CSVHeader, ",") {
+		t.Errorf("header = %q", header)
+	}
+	if !strings.Contains(out, `"Say ""Hello"", <World> & Co"`) {
+		t.Errorf("title not quoted:\n%s", out)
+	}
+}
+
+func TestXSPFEscaping(t *testing.T) {
+	out := write(t, exporter.XSPF, testTracks[:1])
+
+	for _, want := range []string{
+		"<title>Mix &lt;1&gt; &amp; &#34;friends&#34;</title>",
+		"<identifier>spotify:track:1</identifier>",
+		"<identifier>isrc:USQX91700001</identifier>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestJSONEnvelope(t *testing.T) {
+	for _, tracks := range [][]*exporter.Track{nil, testTracks} {
+		out := write(t, exporter.JSON, tracks)
+
+		var doc struct {
+			Version  int                `json:"version"`
+			Playlist *exporter.Playlist `json:"playlist"`
+			Tracks   []*exporter.Track  `json:"tracks"`
+		}
+		if err := json.Unmarshal([]byte(out), &doc); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, out)
+		}
+		if doc.Version != exporter.JSONVersion {
+			t.Errorf("version = %d, want %d", doc.Version, exporter.JSONVersion)
+		}
+		if !reflect.DeepEqual(doc.Playlist, testPlaylist) {
+			t.Errorf("playlist = %+v, want %+v", doc.Playlist, testPlaylist)
+		}
+		if doc.Tracks == nil || len(doc.Tracks) != len(tracks) {
+			t.Errorf("got %d tracks, want %d\n%s", len(doc.Tracks), len(tracks), out)
+		}
+	}
+}
+
+func TestExportSkipsNullTracks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/playlists/p" {
+			w.Write([]byte(`{"id":"p","name":"N","owner":{"id":"o"}}`))
+			return
+		}
+		w.Write([]byte(`{"total":2,"items":[
+			{"track":null},
+			{"track":{"uri":"spotify:track:a","name":"A","artists":[{"name":"X"}],"duration_ms":1000}}
+		]}`))
+	}))
+	defer srv.Close()
+	c := spotify.NewClient("token", spotify.WithBaseURL(srv.URL))
+
+	for _, format := range formats {
+		var buf bytes.Buffer
+		if err := exporter.Export(context.Background(), c, "p", format, &buf); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := importer.Read(format, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].URI != "spotify:track:a" {
+			t.Errorf("%s: exported %+v, want only spotify:track:a", format, entries)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// JSONVersion is the version of the JSON export schema:
+//
+//	{"version": 1, "playlist": Playlist, "tracks": [Track, ...]}
+const JSONVersion = 1
+
+// jsonWriter streams the JSON schema, writing the tracks array element by
+// element.
+type jsonWriter struct {
+	w      *bufio.Writer
+	tracks int
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	return &jsonWriter{w: bufio.NewWriter(w)}
+}
+
+func (j *jsonWriter) Begin(p *Playlist) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	j.w.WriteString(`{"version":`)
+	j.w.WriteString(strconv.Itoa(JSONVersion))
+	j.w.WriteString(`,"playlist":`)
+	j.w.Write(data)
+	_, err = j.w.WriteString(`,"tracks":[`)
+	return err
+}
+
+func (j *jsonWriter) Track(t *Track) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	if j.tracks > 0 {
+		j.w.WriteByte(',')
+	}
+	j.w.WriteString("\n")
+	j.tracks++
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) End() error {
+	if _, err := j.w.WriteString("\n]}\n"); err != nil {
+		return err
+	}
+	return j.w.Flush()
+}
@@ -0,0 +1,40 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// m3uWriter writes extended M3U.
+type m3uWriter struct {
+	w *bufio.Writer
+}
+
+func newM3UWriter(w io.Writer) *m3uWriter {
+	return &m3uWriter{w: bufio.NewWriter(w)}
+}
+
+func (m *m3uWriter) Begin(p *Playlist) error {
+	_, err := fmt.Fprintf(m.w, "#EXTM3U\n#PLAYLIST:%s\n", m3uLine(p.Name))
+	return err
+}
+
+func (m *m3uWriter) Track(t *Track) error {
+	seconds := -1
+	if t.DurationMS > 0 {
+		seconds = int((t.DurationMS + 500) / 1000)
+	}
+	_, err := fmt.Fprintf(m.w, "#EXTINF:%d,%s - %s\n%s\n", seconds, m3uLine(joinArtists(t.Artists)), m3uLine(t.Title), t.Location())
+	return err
+}
+
+func (m *m3uWriter) End() error {
+	return m.w.Flush()
+}
+
+// m3uLine keeps a value on a single line.
+func m3uLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,109 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// xspfNamespace is the XML namespace of XSPF version 1.
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+// xspfTrack is a track element of an XSPF playlist.
+// https://www.xspf.org/spec#4-1-1-2-14-1-1-track
+type xspfTrack struct {
+	XMLName  xml.Name `xml:"track"`
+	Location string   `xml:"location,omitempty"`
+	// Identifiers hold the Spotify URI and, if known, the ISRC as an
+	// "isrc:" URN.
+	Identifiers []string `xml:"identifier,omitempty"`
+	Title       string   `xml:"title,omitempty"`
+	Creator     string   `xml:"creator,omitempty"`
+	Album       string   `xml:"album,omitempty"`
+	Duration    int64    `xml:"duration,omitempty"`
+}
+
+// xspfWriter streams an XSPF playlist, encoding the track list one track at
+// a time.
+type xspfWriter struct {
+	w   io.Writer
+	enc *xml.Encoder
+}
+
+func newXSPFWriter(w io.Writer) *xspfWriter {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &xspfWriter{w: w, enc: enc}
+}
+
+func (x *xspfWriter) Begin(p *Playlist) error {
+	if _, err := io.WriteString(x.w, xml.Header); err != nil {
+		return err
+	}
+
+	playlist := xml.StartElement{
+		Name: xml.Name{Local: "playlist"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "version"}, Value: "1"},
+			{Name: xml.Name{Local: "xmlns"}, Value: xspfNamespace},
+		},
+	}
+	if err := x.enc.EncodeToken(playlist); err != nil {
+		return err
+	}
+	if err := x.element("title", p.Name); err != nil {
+		return err
+	}
+	if err := x.element("creator", p.Owner); err != nil {
+		return err
+	}
+	if err := x.element("annotation", p.Description); err != nil {
+		return err
+	}
+	if err := x.element("location", p.URL); err != nil {
+		return err
+	}
+	if err := x.element("identifier", p.URI); err != nil {
+		return err
+	}
+
+	return x.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "trackList"}})
+}
+
+func (x *xspfWriter) Track(t *Track) error {
+	track := &xspfTrack{
+		Location: t.Location(),
+		Title:    t.Title,
+		Creator:  joinArtists(t.Artists),
+		Album:    t.Album,
+		Duration: t.DurationMS,
+	}
+	if t.URI != "" {
+		track.Identifiers = append(track.Identifiers, t.URI)
+	}
+	if t.ISRC != "" {
+		track.Identifiers = append(track.Identifiers, "isrc:"+t.ISRC)
+	}
+	return x.enc.Encode(track)
+}
+
+func (x *xspfWriter) End() error {
+	if err := x.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "trackList"}}); err != nil {
+		return err
+	}
+	if err := x.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "playlist"}}); err != nil {
+		return err
+	}
+	if err := x.enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, "\n")
+	return err
+}
+
+// element writes a simple element, skipping empty values.
+func (x *xspfWriter) element(name, value string) error {
+	if value == "" {
+		return nil
+	}
+	return x.enc.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: name}})
+}
@@ -2,6 +2,9 @@ module github.com/josuerosadeavila/spotify-client
 
 go 1.19
 
-require github.com/pkg/browser v0.0.0-20210911075715-681adbf594b8
+require (
+	github.com/pkg/browser v0.0.0-20210911075715-681adbf594b8
+	golang.org/x/crypto v0.17.0
+)
 
-require golang.org/x/sys v0.0.0-20210616045830-e2b7044e8c71 // indirect
+require golang.org/x/sys v0.15.0 // indirect
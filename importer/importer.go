@@ -0,0 +1,393 @@
+// Package importer reads playlists exported from other services as M3U, CSV
+// or XSPF, matches their tracks to Spotify tracks and saves them as a
+// Spotify playlist.
+//
+// Entries are matched by the Spotify URI they refer to, then by ISRC and
+// then by searching for their title and artists and scoring the results.
+// Entries without a confident match are reported instead of guessed.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	spotify "github.com/josuerosadeavila/spotify-client"
+	"github.com/josuerosadeavila/spotify-client/exporter"
+)
+
+// Default matching thresholds, see Options.
+const (
+	DefaultMinScore        = 0.75
+	DefaultAmbiguityMargin = 0.05
+)
+
+// searchLimit is how many search results are scored per entry.
+const searchLimit = 10
+
+// Status is the outcome of matching an entry.
+type Status int
+
+const (
+	// Matched entries have a single confident match.
+	Matched Status = iota
+	// Ambiguous entries have several candidates scoring about as well.
+	Ambiguous
+	// Unmatched entries have no candidate scoring well enough.
+	Unmatched
+)
+
+func (s Status) String() string {
+	switch s {
+	case Matched:
+		return "matched"
+	case Ambiguous:
+		return "ambiguous"
+	case Unmatched:
+		return "unmatched"
+	default:
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+}
+
+// Options configures matching and importing.
+type Options struct {
+	// Market restricts search results to tracks playable in the given
+	// country.
+	Market string
+	// MinScore is the lowest score, from 0 to 1, a search result needs to
+	// match. Zero uses DefaultMinScore.
+	MinScore float64
+	// AmbiguityMargin is how close to the best score another candidate has
+	// to be to make an entry ambiguous. Zero uses DefaultAmbiguityMargin.
+	AmbiguityMargin float64
+	// Workers is how many entries are matched concurrently. Zero or less
+	// matches one entry at a time.
+	Workers int
+	// IncludeAmbiguous adds the best candidate of ambiguous entries to the
+	// playlist instead of leaving them out.
+	IncludeAmbiguous bool
+	// DryRun matches the entries without changing any playlist. For
+	// playlists that would be synced, the planned changes are still
+	// reported.
+	DryRun bool
+}
+
+func (o *Options) minScore() float64 {
+	if o.MinScore <= 0 {
+		return DefaultMinScore
+	}
+	return o.MinScore
+}
+
+func (o *Options) ambiguityMargin() float64 {
+	if o.AmbiguityMargin <= 0 {
+		return DefaultAmbiguityMargin
+	}
+	return o.AmbiguityMargin
+}
+
+// Candidate is a Spotify track considered for an entry.
+type Candidate struct {
+	URI     string
+	Title   string
+	Artists []string
+	Album   string
+	Score   float64
+}
+
+// Match is the result of matching an entry.
+type Match struct {
+	Entry  *Entry
+	Status Status
+	// URI is the matched track, or for ambiguous entries the best
+	// candidate.
+	URI   string
+	Score float64
+	// Candidates are the search results considered, best first.
+	Candidates []Candidate
+}
+
+// Resolve matches entries to Spotify tracks. The matches are in the order of
+// entries.
+func Resolve(ctx context.Context, c *spotify.Client, entries []*Entry, opts *Options) ([]*Match, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	matches := make([]*Match, len(entries))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				m, err := resolve(ctx, c, entries[i], opts)
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("importer: match line %d: %w", entries[i].Line, err)
+						cancel()
+					})
+					continue
+				}
+				matches[i] = m
+			}
+		}()
+	}
+
+feed:
+	for i := range entries {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+func resolve(ctx context.Context, c *spotify.Client, entry *Entry, opts *Options) (*Match, error) {
+	if entry.URI != "" {
+		return &Match{Entry: entry, Status: Matched, URI: entry.URI, Score: 1}, nil
+	}
+
+	if entry.ISRC != "" {
+		candidates, err := search(ctx, c, entry, "isrc:"+entry.ISRC, opts)
+		if err != nil {
+			return nil, err
+		}
+		// An ISRC identifies the recording, so any result is a match; the
+		// score only picks the best of several releases.
+		if len(candidates) > 0 {
+			return &Match{Entry: entry, Status: Matched, URI: candidates[0].URI, Score: 1, Candidates: candidates}, nil
+		}
+	}
+
+	if entry.Title == "" {
+		return &Match{Entry: entry, Status: Unmatched}, nil
+	}
+
+	// Remaster and featuring notes differ between services, so they are
+	// left out of the query and only count towards the score.
+	query := `track:"` + searchTerm(normalizeTitle(entry.Title)) + `"`
+	if len(entry.Artists) > 0 {
+		query += ` artist:"` + searchTerm(entry.Artists[0]) + `"`
+	}
+	candidates, err := search(ctx, c, entry, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		// Field filters are strict; try the words alone.
+		query = normalizeTitle(entry.Title)
+		if len(entry.Artists) > 0 {
+			query += " " + normalize(entry.Artists[0])
+		}
+		if candidates, err = search(ctx, c, entry, query, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	m := &Match{Entry: entry, Status: Unmatched, Candidates: candidates}
+	if len(candidates) == 0 || candidates[0].Score < opts.minScore() {
+		return m, nil
+	}
+
+	m.URI, m.Score = candidates[0].URI, candidates[0].Score
+	m.Status = Matched
+	if len(candidates) > 1 && candidates[1].Score >= opts.minScore() && m.Score-candidates[1].Score < opts.ambiguityMargin() {
+		m.Status = Ambiguous
+	}
+	return m, nil
+}
+
+// search returns the distinct tracks found for query, scored against entry,
+// best first.
+func search(ctx context.Context, c *spotify.Client, entry *Entry, query string, opts *Options) ([]Candidate, error) {
+	it := c.Search.Tracks(query, &spotify.SearchOptions{Market: opts.Market, Limit: searchLimit})
+	tracks, err := spotify.Collect(ctx, it, searchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var candidates []Candidate
+	for _, t := range tracks {
+		if seen[t.URI] {
+			continue
+		}
+		seen[t.URI] = true
+
+		candidate := Candidate{
+			URI:     t.URI,
+			Title:   t.Name,
+			Album:   t.Album.Name,
+			Artists: make([]string, 0, len(t.Artists)),
+			Score:   score(entry, t),
+		}
+		for _, a := range t.Artists {
+			candidate.Artists = append(candidate.Artists, a.Name)
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, nil
+}
+
+// searchTerm makes s safe to quote in a search field filter.
+func searchTerm(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, `"`, " "))
+}
+
+// Target is the playlist an import saves to.
+type Target struct {
+	// PlaylistID is the playlist to update. When empty, a new playlist is
+	// created.
+	PlaylistID string
+	// Append adds the matched tracks to the end of the playlist. Otherwise
+	// the playlist is synced to hold exactly the matched tracks, in order.
+	Append bool
+
+	// UserID owns the new playlist; empty means the current user.
+	UserID      string
+	Name        string
+	Description string
+	Public      bool
+}
+
+// Result is the outcome of an import.
+type Result struct {
+	Matches []*Match
+	// PlaylistID is the playlist saved to; empty on a dry run that would
+	// create one.
+	PlaylistID string
+	// SnapshotID is the playlist snapshot after the import.
+	SnapshotID string
+	// Sync holds the changes made to a synced playlist.
+	Sync *spotify.SyncReport
+}
+
+// Unmatched returns the matches with no confident candidate.
+func (r *Result) Unmatched() []*Match {
+	return r.withStatus(Unmatched)
+}
+
+// Ambiguous returns the matches with several close candidates.
+func (r *Result) Ambiguous() []*Match {
+	return r.withStatus(Ambiguous)
+}
+
+func (r *Result) withStatus(status Status) []*Match {
+	var matches []*Match
+	for _, m := range r.Matches {
+		if m.Status == status {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// Import reads a playlist file in the given format, matches its entries and
+// saves the matched tracks to target.
+func Import(ctx context.Context, c *spotify.Client, r io.Reader, format exporter.Format, target *Target, opts *Options) (*Result, error) {
+	entries, err := Read(format, r)
+	if err != nil {
+		return nil, err
+	}
+	return ImportEntries(ctx, c, entries, target, opts)
+}
+
+// ImportEntries matches entries and saves the matched tracks to target. If
+// adding the tracks to a new playlist fails, the result is returned along
+// with the error so the playlist can be found.
+func ImportEntries(ctx context.Context, c *spotify.Client, entries []*Entry, target *Target, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if target == nil {
+		return nil, errors.New("importer: no target playlist")
+	}
+	if target.PlaylistID == "" && target.Name == "" {
+		return nil, errors.New("importer: new playlist needs a name")
+	}
+
+	matches, err := Resolve(ctx, c, entries, opts)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Matches: matches, PlaylistID: target.PlaylistID}
+
+	var uris []string
+	for _, m := range matches {
+		if m.Status == Matched || (m.Status == Ambiguous && opts.IncludeAmbiguous) {
+			uris = append(uris, m.URI)
+		}
+	}
+
+	switch {
+	case target.PlaylistID != "" && !target.Append:
+		report, err := c.Playlist.Sync(ctx, target.PlaylistID, uris, &spotify.SyncOptions{DryRun: opts.DryRun})
+		if err != nil {
+			return nil, err
+		}
+		result.Sync = report
+		result.SnapshotID = report.SnapshotID
+		return result, nil
+	case opts.DryRun:
+		return result, nil
+	}
+
+	if target.PlaylistID == "" {
+		userID := target.UserID
+		if userID == "" {
+			me, err := c.User.Me(ctx)
+			if err != nil {
+				return nil, err
+			}
+			userID = me.ID
+		}
+
+		playlist, err := c.Playlist.Create(ctx, userID, target.Name, target.Public, false, target.Description)
+		if err != nil {
+			return nil, err
+		}
+		result.PlaylistID = playlist.ID
+		result.SnapshotID = playlist.SnapshotID
+	}
+
+	if len(uris) > 0 {
+		snapshotID, err := c.Playlist.AddItemsBulk(ctx, result.PlaylistID, uris, -1, nil)
+		if err != nil {
+			return result, err
+		}
+		result.SnapshotID = snapshotID
+	}
+	return result, nil
+}
@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	spotify "github.com/josuerosadeavila/spotify-client"
+)
+
+// catalog answers searches from a fixed map of queries to tracks and
+// records the queries it gets.
+type catalog struct {
+	mu      sync.Mutex
+	tracks  map[string][]map[string]interface{}
+	queries []string
+}
+
+func (c *catalog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	c.mu.Lock()
+	c.queries = append(c.queries, q)
+	items := c.tracks[q]
+	c.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tracks": map[string]interface{}{"items": items, "total": len(items), "limit": searchLimit},
+	})
+}
+
+func catalogTrack(uri, name, artist string, d time.Duration) map[string]interface{} {
+	return map[string]interface{}{
+		"uri":         uri,
+		"name":        name,
+		"duration_ms": d.Milliseconds(),
+		"artists":     []map[string]string{{"name": artist}},
+		"album":       map[string]string{"name": "Album"},
+	}
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *spotify.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return spotify.NewClient("token", spotify.WithBaseURL(srv.URL))
+}
+
+func TestResolve(t *testing.T) {
+	cat := &catalog{tracks: map[string][]map[string]interface{}{
+		"isrc:GBUM71029604": {catalogTrack("spotify:track:isrc", "Hello", "Adele", 295*time.Second)},
+		`track:"bohemian rhapsody" artist:"Queen"`: {
+			catalogTrack("spotify:track:live", "Bohemian Rhapsody - Live Aid", "Queen", 360*time.Second),
+			catalogTrack("spotify:track:bohemian", "Bohemian Rhapsody - Remastered 2011", "Queen", 354*time.Second),
+		},
+		"strange title some artist": {catalogTrack("spotify:track:loose", "Strange Title", "Some Artist", 0)},
+		`track:"song" artist:"Band"`: {
+			catalogTrack("spotify:track:single", "Song", "Band", 200*time.Second),
+			catalogTrack("spotify:track:album", "Song", "Band", 200*time.Second),
+		},
+		`track:"cover" artist:"Original"`: {catalogTrack("spotify:track:cover", "Cover", "Tribute Band", 100*time.Second)},
+	}}
+	c := newTestClient(t, cat)
+
+	entries := []*Entry{
+		{Line: 1, URI: "spotify:track:direct", Title: "Direct"},
+		{Line: 2, ISRC: "GBUM71029604", Title: "Hello", Artists: []string{"Adele"}},
+		// No ISRC result, so the search by title and artist is used.
+		{Line: 3, ISRC: "XX0000000000", Title: "Bohemian Rhapsody (Remastered)", Artists: []string{"Queen"}, Duration: 354 * time.Second},
+		// No field filter result, so the plain search is used.
+		{Line: 4, Title: "Strange Title", Artists: []string{"Some Artist"}},
+		{Line: 5, Title: "Song", Artists: []string{"Band"}, Duration: 200 * time.Second},
+		{Line: 6, Title: "Cover", Artists: []string{"Original"}},
+		{Line: 7, Title: "Nothing", Artists: []string{"Nobody"}},
+		{Line: 8, Album: "No title"},
+	}
+
+	want := []struct {
+		status Status
+		uri    string
+	}{
+		{Matched, "spotify:track:direct"},
+		{Matched, "spotify:track:isrc"},
+		{Matched, "spotify:track:bohemian"},
+		{Matched, "spotify:track:loose"},
+		{Ambiguous, "spotify:track:single"},
+		{Unmatched, ""},
+		{Unmatched, ""},
+		{Unmatched, ""},
+	}
+
+	matches, err := Resolve(context.Background(), c, entries, &Options{Workers: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range matches {
+		if m.Entry != entries[i] || m.Status != want[i].status || m.URI != want[i].uri {
+			t.Errorf("line %d: %v %q (score %.3f), want %v %q", entries[i].Line, m.Status, m.URI, m.Score, want[i].status, want[i].uri)
+		}
+	}
+	if len(matches[5].Candidates) != 1 {
+		t.Errorf("unmatched line 6 has %d candidates, want the rejected one", len(matches[5].Candidates))
+	}
+
+	for _, q := range cat.queries {
+		if strings.Contains(q, "direct") || strings.Contains(q, "No title") {
+			t.Errorf("searched for %q", q)
+		}
+	}
+}
+
+func TestResolveError(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+
+	_, err := Resolve(context.Background(), c, []*Entry{{Line: 4, Title: "A"}}, nil)
+	if !spotify.IsForbidden(err) || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("err = %v, want a 403 for line 4", err)
+	}
+}
+
+func TestImportNewPlaylist(t *testing.T) {
+	cat := &catalog{tracks: map[string][]map[string]interface{}{
+		`track:"one" artist:"X"`: {catalogTrack("spotify:track:one", "One", "X", 0)},
+	}}
+	var added []string
+	mux := http.NewServeMux()
+	mux.Handle("/v1/search", cat)
+	mux.HandleFunc("/v1/me", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"id":"user"}`)
+	})
+	mux.HandleFunc("/v1/users/user/playlists", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"id":"new","snapshot_id":"s0"}`)
+	})
+	mux.HandleFunc("/v1/playlists/new/tracks", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			URIs []string `json:"uris"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		added = append(added, body.URIs...)
+		fmt.Fprintf(w, `{"snapshot_id":"s%d"}`, len(added))
+	})
+	c := newTestClient(t, mux)
+
+	in := "title,artists,uri\nOne,X,\nTwo,Y,\nThree,Z,spotify:track:three\n"
+	res, err := Import(context.Background(), c, strings.NewReader(in), "csv", &Target{Name: "Imported"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.PlaylistID != "new" || res.SnapshotID != "s2" {
+		t.Errorf("result playlist %q at %q, want new at s2", res.PlaylistID, res.SnapshotID)
+	}
+	if want := []string{"spotify:track:one", "spotify:track:three"}; !reflect.DeepEqual(added, want) {
+		t.Errorf("added %q, want %q", added, want)
+	}
+	if u := res.Unmatched(); len(u) != 1 || u[0].Entry.Title != "Two" {
+		t.Errorf("unmatched = %+v, want Two", u)
+	}
+}
+
+func TestImportTarget(t *testing.T) {
+	c := spotify.NewClient("token")
+	for _, target := range []*Target{nil, {}} {
+		if _, err := ImportEntries(context.Background(), c, nil, target, nil); err == nil {
+			t.Errorf("ImportEntries with target %+v succeeded", target)
+		}
+	}
+}
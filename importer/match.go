@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	spotify "github.com/josuerosadeavila/spotify-client"
+)
+
+// Weights of the parts of a match score. Parts the entry doesn't have are
+// left out and the others scaled up.
+const (
+	titleWeight    = 0.55
+	artistWeight   = 0.3
+	albumWeight    = 0.05
+	durationWeight = 0.1
+)
+
+// Durations within durationSlack of each other score 1, falling to 0 at
+// durationLimit.
+const (
+	durationSlack = 2 * time.Second
+	durationLimit = 30 * time.Second
+)
+
+// noiseWords mark the parts of a title that don't tell recordings apart, like
+// "(feat. X)" or "- 2011 Remaster".
+var noiseWords = regexp.MustCompile(`\b(feat|ft|featuring|with|remaster|remastered|mono|stereo|version|edit|explicit|clean)\b`)
+
+// bracketed matches parenthesized or bracketed parts of a title.
+var bracketed = regexp.MustCompile(`\s*[(\[][^)\]]*[)\]]`)
+
+// score rates how well track matches entry, from 0 to 1.
+func score(entry *Entry, track *spotify.Track) float64 {
+	total := titleWeight * similarity(normalizeTitle(entry.Title), normalizeTitle(track.Name))
+	weights := titleWeight
+
+	if len(entry.Artists) > 0 {
+		artists := make([]string, 0, len(track.Artists))
+		for _, a := range track.Artists {
+			artists = append(artists, a.Name)
+		}
+		total += artistWeight * artistSimilarity(entry.Artists, artists)
+		weights += artistWeight
+	}
+	if entry.Album != "" {
+		total += albumWeight * similarity(normalizeTitle(entry.Album), normalizeTitle(track.Album.Name))
+		weights += albumWeight
+	}
+	if entry.Duration > 0 && track.Duration != nil {
+		total += durationWeight * durationSimilarity(entry.Duration, track.Duration.Duration)
+		weights += durationWeight
+	}
+
+	return total / weights
+}
+
+// artistSimilarity compares artist lists both as a whole and by their first
+// artist, since names containing commas may have been split apart.
+func artistSimilarity(a, b []string) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+
+	whole := similarity(normalize(strings.Join(a, " ")), normalize(strings.Join(b, " ")))
+	first := similarity(normalize(a[0]), normalize(b[0]))
+	if first > whole {
+		return first
+	}
+	return whole
+}
+
+func durationSimilarity(a, b time.Duration) float64 {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	switch {
+	case d <= durationSlack:
+		return 1
+	case d >= durationLimit:
+		return 0
+	default:
+		return 1 - float64(d-durationSlack)/float64(durationLimit-durationSlack)
+	}
+}
+
+// normalizeTitle normalizes a track or album title, dropping bracketed and
+// dash-separated parts made of noise words.
+func normalizeTitle(s string) string {
+	s = strings.ToLower(s)
+	s = bracketed.ReplaceAllStringFunc(s, func(part string) string {
+		if noiseWords.MatchString(part) {
+			return ""
+		}
+		return part
+	})
+	if i := strings.LastIndex(s, " - "); i > 0 && noiseWords.MatchString(s[i:]) {
+		s = s[:i]
+	}
+	return normalize(s)
+}
+
+// normalize lowercases s, spells out "&" and reduces punctuation and runs of
+// spaces to single spaces.
+func normalize(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "&", " and ")
+
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else if r != '\'' && r != '’' {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// similarity returns 1 minus the edit distance between a and b relative to
+// the longer of them.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if v := prev[j] + 1; v < cur[j] {
+				cur[j] = v
+			}
+			if v := cur[j-1] + 1; v < cur[j] {
+				cur[j] = v
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,90 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	spotify "github.com/josuerosadeavila/spotify-client"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := map[string]string{
+		"Bohemian Rhapsody - Remastered 2011": "bohemian rhapsody",
+		"Bohemian Rhapsody - Live Aid":        "bohemian rhapsody live aid",
+		"Señorita (feat. Camila Cabello)":     "señorita",
+		"Song [Radio Edit]":                   "song",
+		"Song (Live)":                         "song live",
+		"Rock & Roll":                         "rock and roll",
+		"Don’t Stop Me Now":                   "dont stop me now",
+		"  Hello,   World!  ":                 "hello world",
+	}
+	for in, want := range tests {
+		if got := normalizeTitle(in); got != want {
+			t.Errorf("normalizeTitle(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func track(name string, d time.Duration, artists ...string) *spotify.Track {
+	t := &spotify.Track{Name: name, Duration: &spotify.Duration{Duration: d}}
+	for _, a := range artists {
+		t.Artists = append(t.Artists, spotify.Artist{Name: a})
+	}
+	return t
+}
+
+func TestScore(t *testing.T) {
+	entry := &Entry{Title: "Bohemian Rhapsody", Artists: []string{"Queen"}, Duration: 354 * time.Second}
+
+	tests := []struct {
+		name  string
+		track *spotify.Track
+		match bool
+	}{
+		{"same", track("Bohemian Rhapsody", 354*time.Second, "Queen"), true},
+		{"remaster", track("Bohemian Rhapsody - Remastered 2011", 355*time.Second, "Queen"), true},
+		{"typo", track("Bohemian Rapsody", 354*time.Second, "Queen"), true},
+		{"featured artist", track("Bohemian Rhapsody", 354*time.Second, "Queen", "Someone"), true},
+		{"cover", track("Bohemian Rhapsody", 354*time.Second, "Panic! At The Disco"), false},
+		{"other song", track("Killer Queen", 180*time.Second, "Queen"), false},
+	}
+	for _, tt := range tests {
+		s := score(entry, tt.track)
+		if s < 0 || s > 1 {
+			t.Errorf("%s: score %.3f out of range", tt.name, s)
+		}
+		if (s >= DefaultMinScore) != tt.match {
+			t.Errorf("%s: score %.3f, want match %v at %.2f", tt.name, s, tt.match, DefaultMinScore)
+		}
+	}
+
+	// Versions of the same recording are too close to pick from.
+	remaster := score(entry, track("Bohemian Rhapsody - 2011 Remaster", 354*time.Second, "Queen"))
+	original := score(entry, track("Bohemian Rhapsody", 354*time.Second, "Queen"))
+	if original-remaster >= DefaultAmbiguityMargin {
+		t.Errorf("original %.3f and remaster %.3f are not ambiguous", original, remaster)
+	}
+	// A live version is far enough from the studio one.
+	live := score(entry, track("Bohemian Rhapsody - Live Aid", 360*time.Second, "Queen"))
+	if original-live < DefaultAmbiguityMargin {
+		t.Errorf("original %.3f and live %.3f are ambiguous", original, live)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"abc", "abc", 1},
+		{"abc", "", 0},
+		{"kitten", "sitting", 1 - 3.0/7},
+		{"señor", "senor", 0.8},
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
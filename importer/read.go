@@ -0,0 +1,367 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josuerosadeavila/spotify-client/exporter"
+)
+
+// Entry is one track read from a playlist file.
+type Entry struct {
+	// Line is the line of the entry in M3U files, the row in CSV files and
+	// the position of the track in XSPF and JSON files, counting from 1.
+	Line     int
+	Title    string
+	Artists  []string
+	Album    string
+	ISRC     string
+	Duration time.Duration
+	// URI is the Spotify track URI, when the file refers to one.
+	URI string
+	// Location is the file path or URL the entry refers to, if any.
+	Location string
+}
+
+// Read reads the entries of a playlist file in the given format. The JSON
+// format is the one written by the exporter package.
+func Read(format exporter.Format, r io.Reader) ([]*Entry, error) {
+	switch format {
+	case exporter.M3U:
+		return ReadM3U(r)
+	case exporter.CSV:
+		return ReadCSV(r)
+	case exporter.XSPF:
+		return ReadXSPF(r)
+	case exporter.JSON:
+		return readJSON(r)
+	default:
+		return nil, fmt.Errorf("importer: unknown format %q", format)
+	}
+}
+
+// ReadM3U reads a plain or extended M3U playlist. Titles and artists come
+// from "#EXTINF:seconds,Artist - Title" lines, or from the file name when
+// there is none.
+func ReadM3U(r io.Reader) ([]*Entry, error) {
+	var entries []*Entry
+	var info *Entry
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info = parseEXTINF(strings.TrimPrefix(line, "#EXTINF:"))
+			info.Line = n
+		case strings.HasPrefix(line, "#"):
+		default:
+			entry := info
+			if entry == nil {
+				entry = parseArtistTitle(locationName(line))
+				entry.Line = n
+			}
+			entry.Location = line
+			entry.URI = spotifyTrackURI(line)
+			entries = append(entries, entry)
+			info = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("importer: read m3u: %w", err)
+	}
+
+	return entries, nil
+}
+
+// parseEXTINF parses the value of an #EXTINF line: the duration in seconds,
+// optional attributes and the display name after the first comma.
+func parseEXTINF(value string) *Entry {
+	info, name := value, ""
+	if i := strings.Index(value, ","); i >= 0 {
+		info, name = value[:i], value[i+1:]
+	}
+
+	entry := parseArtistTitle(name)
+	if fields := strings.Fields(info); len(fields) > 0 {
+		if seconds, err := strconv.Atoi(fields[0]); err == nil && seconds > 0 {
+			entry.Duration = time.Duration(seconds) * time.Second
+		}
+	}
+	return entry
+}
+
+// parseArtistTitle splits an "Artist - Title" display name.
+func parseArtistTitle(name string) *Entry {
+	name = strings.TrimSpace(name)
+	if i := strings.Index(name, " - "); i >= 0 {
+		return &Entry{
+			Artists: splitArtists(name[:i]),
+			Title:   strings.TrimSpace(name[i+3:]),
+		}
+	}
+	return &Entry{Title: name}
+}
+
+// locationName returns the file name of a path or URL without its extension.
+func locationName(location string) string {
+	if u, err := url.Parse(location); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		location = u.Path
+	}
+	location = strings.ReplaceAll(location, `\`, "/")
+	name := path.Base(location)
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// csvColumns maps the header names accepted by ReadCSV, lowercased, to the
+// field they hold. They cover the exporter package and common export tools.
+var csvColumns = map[string]string{
+	"title":          "title",
+	"name":           "title",
+	"track":          "title",
+	"track name":     "title",
+	"song":           "title",
+	"artists":        "artists",
+	"artist":         "artists",
+	"artist name":    "artists",
+	"artist name(s)": "artists",
+	"album":          "album",
+	"album name":     "album",
+	"isrc":           "isrc",
+	"duration_ms":    "duration_ms",
+	"duration (ms)":  "duration_ms",
+	"duration":       "duration",
+	"uri":            "uri",
+	"track uri":      "uri",
+	"spotify uri":    "uri",
+	"url":            "uri",
+}
+
+// ReadCSV reads a CSV file with a header row. Columns are recognized by
+// name, see csvColumns; at least a title, ISRC or URI column is required.
+// A "duration" column holds seconds or "m:ss".
+func ReadCSV(r io.Reader) ([]*Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("importer: read csv: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := csvColumns[name]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	_, hasTitle := columns["title"]
+	_, hasISRC := columns["isrc"]
+	_, hasURI := columns["uri"]
+	if !hasTitle && !hasISRC && !hasURI {
+		return nil, errors.New("importer: csv has no title, isrc or uri column")
+	}
+
+	var entries []*Entry
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("importer: read csv: %w", err)
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		entry := &Entry{
+			Line:    row,
+			Title:   field("title"),
+			Artists: splitArtists(field("artists")),
+			Album:   field("album"),
+			ISRC:    field("isrc"),
+		}
+		if uri := field("uri"); uri != "" {
+			entry.Location = uri
+			entry.URI = spotifyTrackURI(uri)
+		}
+		if ms, err := strconv.ParseInt(field("duration_ms"), 10, 64); err == nil {
+			entry.Duration = time.Duration(ms) * time.Millisecond
+		} else {
+			entry.Duration = parseDuration(field("duration"))
+		}
+		if entry.Title == "" && entry.ISRC == "" && entry.URI == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseDuration parses seconds or "m:ss", returning zero when it can't.
+func parseDuration(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+
+	var seconds float64
+	for _, part := range strings.Split(s, ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + v
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// xspfTrack is a track element of an XSPF playlist; locations and
+// identifiers may repeat.
+// https://www.xspf.org/spec#4-1-1-2-14-1-1-track
+type xspfTrack struct {
+	Locations   []string `xml:"location"`
+	Identifiers []string `xml:"identifier"`
+	Title       string   `xml:"title"`
+	Creator     string   `xml:"creator"`
+	Album       string   `xml:"album"`
+	Duration    int64    `xml:"duration"`
+}
+
+// ReadXSPF reads an XSPF playlist. Identifiers may be Spotify URIs or
+// "isrc:" URNs.
+func ReadXSPF(r io.Reader) ([]*Entry, error) {
+	dec := xml.NewDecoder(r)
+
+	var entries []*Entry
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("importer: read xspf: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "track" {
+			continue
+		}
+
+		var track xspfTrack
+		if err := dec.DecodeElement(&track, &start); err != nil {
+			return nil, fmt.Errorf("importer: read xspf: %w", err)
+		}
+
+		entry := &Entry{
+			Line:     len(entries) + 1,
+			Title:    strings.TrimSpace(track.Title),
+			Artists:  splitArtists(track.Creator),
+			Album:    strings.TrimSpace(track.Album),
+			Duration: time.Duration(track.Duration) * time.Millisecond,
+		}
+		for _, id := range append(track.Identifiers, track.Locations...) {
+			id = strings.TrimSpace(id)
+			if entry.URI == "" {
+				entry.URI = spotifyTrackURI(id)
+			}
+			if entry.ISRC == "" && strings.HasPrefix(strings.ToLower(id), "isrc:") {
+				entry.ISRC = id[len("isrc:"):]
+			}
+		}
+		if len(track.Locations) > 0 {
+			entry.Location = strings.TrimSpace(track.Locations[0])
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// readJSON reads the JSON written by the exporter package.
+func readJSON(r io.Reader) ([]*Entry, error) {
+	var doc struct {
+		Tracks []*exporter.Track `json:"tracks"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("importer: read json: %w", err)
+	}
+
+	entries := make([]*Entry, 0, len(doc.Tracks))
+	for i, t := range doc.Tracks {
+		entries = append(entries, &Entry{
+			Line:     i + 1,
+			Title:    t.Title,
+			Artists:  t.Artists,
+			Album:    t.Album,
+			ISRC:     t.ISRC,
+			Duration: t.Duration(),
+			URI:      spotifyTrackURI(t.URI),
+			Location: t.Location(),
+		})
+	}
+	return entries, nil
+}
+
+// splitArtists splits a list of artist names separated by semicolons or, if
+// there are none, commas.
+func splitArtists(s string) []string {
+	sep := ","
+	if strings.Contains(s, ";") {
+		sep = ";"
+	}
+
+	var artists []string
+	for _, a := range strings.Split(s, sep) {
+		if a = strings.TrimSpace(a); a != "" {
+			artists = append(artists, a)
+		}
+	}
+	return artists
+}
+
+// spotifyTrackURI returns the track URI a Spotify URI or open.spotify.com
+// URL refers to, or "" for anything else.
+func spotifyTrackURI(s string) string {
+	if strings.HasPrefix(s, "spotify:track:") {
+		return s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || u.Host != "open.spotify.com" {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	// Localized links look like /intl-de/track/<id>.
+	if len(parts) >= 2 && parts[len(parts)-2] == "track" && parts[len(parts)-1] != "" {
+		return "spotify:track:" + parts[len(parts)-1]
+	}
+	return ""
+}
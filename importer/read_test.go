@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadM3U(t *testing.T) {
+	in := "\ufeff#EXTM3U\n" +
+		"#PLAYLIST:Mix\n" +
+		"#EXTINF:354,Queen - Bohemian Rhapsody\n" +
+		"/music/queen/bohemian.mp3\n" +
+		"\n" +
+		"#EXTINF:200 tvg-id=\"x\",Foo, Bar - Baz - Qux\n" +
+		"https://open.spotify.com/intl-de/track/abc?si=1\n" +
+		"C:\\Music\\Artist X - Song Y.flac\n" +
+		"#EXTINF:-1,No Artist\n" +
+		"spotify:track:def\n"
+
+	want := []*Entry{
+		{Line: 3, Title: "Bohemian Rhapsody", Artists: []string{"Queen"}, Duration: 354 * time.Second, Location: "/music/queen/bohemian.mp3"},
+		{Line: 6, Title: "Baz - Qux", Artists: []string{"Foo", "Bar"}, Duration: 200 * time.Second, URI: "spotify:track:abc", Location: "https://open.spotify.com/intl-de/track/abc?si=1"},
+		{Line: 8, Title: "Song Y", Artists: []string{"Artist X"}, Location: `C:\Music\Artist X - Song Y.flac`},
+		{Line: 9, Title: "No Artist", URI: "spotify:track:def", Location: "spotify:track:def"},
+	}
+
+	got, err := ReadM3U(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", entriesString(got), entriesString(want))
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []*Entry
+		wantErr bool
+	}{
+		{
+			name: "exporter",
+			in: "title,artists,album,isrc,duration_ms,added_at,added_by,uri\n" +
+				`"Say ""Hi"", World","Tyler, The Creator; Kali Uchis",Album,USQX91700001,180400,,,spotify:track:1` + "\n",
+			want: []*Entry{{
+				Line: 2, Title: `Say "Hi", World`, Artists: []string{"Tyler, The Creator", "Kali Uchis"}, Album: "Album",
+				ISRC: "USQX91700001", Duration: 180400 * time.Millisecond, URI: "spotify:track:1", Location: "spotify:track:1",
+			}},
+		},
+		{
+			name: "aliases and BOM",
+			in: "\ufeffTrack URI,Track Name,Artist Name(s),Album Name,Duration (ms),ISRC\n" +
+				"https://open.spotify.com/track/2,Song,\"A,B\",Album,1000,\n",
+			want: []*Entry{{
+				Line: 2, Title: "Song", Artists: []string{"A", "B"}, Album: "Album",
+				Duration: time.Second, URI: "spotify:track:2", Location: "https://open.spotify.com/track/2",
+			}},
+		},
+		{
+			name: "m:ss durations and empty rows",
+			in:   "Song,Artist,Duration\nOne,X,3:05\n,,\nTwo,Y,61.5\nThree,Z,soon\n",
+			want: []*Entry{
+				{Line: 2, Title: "One", Artists: []string{"X"}, Duration: 185 * time.Second},
+				{Line: 4, Title: "Two", Artists: []string{"Y"}, Duration: 61500 * time.Millisecond},
+				{Line: 5, Title: "Three", Artists: []string{"Z"}},
+			},
+		},
+		{
+			name:    "no usable column",
+			in:      "artist,album\nX,Y\n",
+			wantErr: true,
+		},
+		{
+			name: "empty",
+			in:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCSV(strings.NewReader(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%s\nwant\n%s", entriesString(got), entriesString(tt.want))
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":        0,
+		"185":     185 * time.Second,
+		"3:05":    185 * time.Second,
+		"1:02:03": time.Hour + 2*time.Minute + 3*time.Second,
+		"2.5":     2500 * time.Millisecond,
+		"3:xx":    0,
+	}
+	for in, want := range tests {
+		if got := parseDuration(in); got != want {
+			t.Errorf("parseDuration(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestReadXSPF(t *testing.T) {
+	in := `<?xml version="1.0" encoding="UTF-8"?>
+<playlist version="1" xmlns="http://xspf.org/ns/0/">
+  <title>Mix</title>
+  <trackList>
+    <track>
+      <location>https://open.spotify.com/track/1</location>
+      <identifier>spotify:track:1</identifier>
+      <identifier>isrc:USQX91700001</identifier>
+      <title>Rock &amp; Roll</title>
+      <creator>Led Zeppelin</creator>
+      <album>IV</album>
+      <duration>220000</duration>
+    </track>
+    <track>
+      <location>file:///music/song.mp3</location>
+      <identifier>ISRC:GBAYE0000001</identifier>
+      <title>Song</title>
+    </track>
+  </trackList>
+</playlist>`
+
+	want := []*Entry{
+		{
+			Line: 1, Title: "Rock & Roll", Artists: []string{"Led Zeppelin"}, Album: "IV", ISRC: "USQX91700001",
+			Duration: 220 * time.Second, URI: "spotify:track:1", Location: "https://open.spotify.com/track/1",
+		},
+		{Line: 2, Title: "Song", ISRC: "GBAYE0000001", Location: "file:///music/song.mp3"},
+	}
+
+	got, err := ReadXSPF(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", entriesString(got), entriesString(want))
+	}
+
+	if _, err := ReadXSPF(strings.NewReader("<playlist><trackList><track>")); err == nil {
+		t.Error("truncated XSPF read without error")
+	}
+}
+
+func TestSpotifyTrackURI(t *testing.T) {
+	tests := map[string]string{
+		"spotify:track:abc":                        "spotify:track:abc",
+		"https://open.spotify.com/track/abc":       "spotify:track:abc",
+		"https://open.spotify.com/track/abc?si=12": "spotify:track:abc",
+		"https://open.spotify.com/intl-de/track/x": "spotify:track:x",
+		"https://open.spotify.com/album/abc":       "",
+		"https://open.spotify.com/track/":          "",
+		"https://example.com/track/abc":            "",
+		"spotify:album:abc":                        "",
+		"/music/track/abc.mp3":                     "",
+	}
+	for in, want := range tests {
+		if got := spotifyTrackURI(in); got != want {
+			t.Errorf("spotifyTrackURI(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func entriesString(entries []*Entry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%+v\n", *e)
+	}
+	return b.String()
+}
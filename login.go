@@ -0,0 +1,269 @@
+package spotifyclient
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/browser"
+)
+
+// defaultCallbackPath is the redirect path used when LoginConfig has no
+// RedirectURI.
+const defaultCallbackPath = "/callback"
+
+// LoginConfig configures an interactive login.
+type LoginConfig struct {
+	ClientID string
+	// ClientSecret selects the authorization code flow. Leave it empty to
+	// log in with PKCE.
+	ClientSecret string
+	Scopes       []Scope
+	// RedirectURI is the loopback URI registered for the app, e.g.
+	// "http://127.0.0.1:8080/callback". When empty, a free port on
+	// 127.0.0.1 is used with the path /callback.
+	RedirectURI string
+	// OpenURL opens the authorization URL. It defaults to opening the
+	// user's browser.
+	OpenURL func(url string) error
+}
+
+// loginResult is what the callback handler reports back to Login.
+type loginResult struct {
+	token *Token
+	err   error
+}
+
+// Login runs the authorization flow in the user's browser. It starts a
+// loopback server to receive the redirect, opens the authorization page and
+// waits until the user approves or denies access, or ctx is done.
+func Login(ctx context.Context, cfg LoginConfig) (*Token, error) {
+	redirectURI, listener, err := listenForCallback(cfg.RedirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := GenerateRandomState()
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	var authURI string
+	var exchange func(ctx context.Context, code string) (*Token, error)
+	if cfg.ClientSecret != "" {
+		authURI = BuildAuthURI(cfg.ClientID, redirectURI.String(), state, cfg.Scopes...)
+		exchange = func(ctx context.Context, code string) (*Token, error) {
+			return RequestToken(ctx, cfg.ClientID, cfg.ClientSecret, code, redirectURI.String())
+		}
+	} else {
+		verifier, challenge, err := CreatePKCEVerifierAndChallenge()
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		authURI = BuildPKCEAuthURI(cfg.ClientID, redirectURI.String(), challenge, state, cfg.Scopes...)
+		exchange = func(ctx context.Context, code string) (*Token, error) {
+			return RequestPKCEToken(ctx, cfg.ClientID, code, redirectURI.String(), verifier)
+		}
+	}
+
+	results := make(chan loginResult, 1)
+	var once sync.Once
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirectURI.Path, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != redirectURI.Path {
+			http.NotFound(w, r)
+			return
+		}
+
+		code, err := callbackCode(r.URL.Query(), state)
+		var token *Token
+		if err == nil {
+			token, err = exchange(ctx, code)
+		}
+
+		renderLoginPage(w, err)
+		once.Do(func() {
+			results <- loginResult{token: token, err: err}
+		})
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	openURL := cfg.OpenURL
+	if openURL == nil {
+		openURL = browser.OpenURL
+	}
+	if err := openURL(authURI); err != nil {
+		return nil, fmt.Errorf("spotify: open authorization page: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-results:
+		return res.token, res.err
+	}
+}
+
+// listenForCallback listens on the address of redirectURI, or on a free
+// loopback port when it is empty, and returns the resulting redirect URI.
+func listenForCallback(redirectURI string) (*url.URL, net.Listener, error) {
+	if redirectURI == "" {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, nil, err
+		}
+		u := &url.URL{Scheme: "http", Host: listener.Addr().String(), Path: defaultCallbackPath}
+		return u, listener, nil
+	}
+
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Scheme != "http" || !isLoopback(u.Hostname()) {
+		return nil, nil, fmt.Errorf("spotify: redirect URI %q is not a loopback http URI", redirectURI)
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	listener, err := net.Listen("tcp", u.Host)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Port 0 picks a free port, which the redirect URI has to name.
+	if u.Port() == "0" {
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u, listener, nil
+}
+
+// isLoopback reports whether host only accepts connections from this
+// machine, so the callback server isn't exposed to the network.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// callbackCode validates the query of an authorization redirect and returns
+// the authorization code.
+func callbackCode(query url.Values, state string) (string, error) {
+	if query.Get("state") != state {
+		return "", errors.New("spotify: authorization failed: state mismatch")
+	}
+	if e := query.Get("error"); e != "" {
+		return "", fmt.Errorf("spotify: authorization failed: %s", e)
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return "", errors.New("spotify: authorization failed: no code in redirect")
+	}
+	return code, nil
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Spotify login</title></head>
+<body style="font-family: sans-serif; text-align: center; margin-top: 4em">
+{{if .}}<h1>Login failed</h1>
+<p>{{.}}</p>
+{{else}}<h1>Login successful</h1>
+<p>You can close this window and return to the application.</p>
+{{end}}</body>
+</html>
+`))
+
+func renderLoginPage(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	loginPage.Execute(w, err)
+}
+
+// LoginHeadless runs the PKCE authorization flow for machines that can't open
+// a browser or receive a redirect. It writes the authorization URL to out and
+// reads from in the URL the browser was redirected to, or just the code in
+// it. The redirect doesn't have to load; copying it from the address bar is
+// enough. cfg.RedirectURI must be set and cfg.OpenURL is ignored.
+func LoginHeadless(ctx context.Context, cfg LoginConfig, in io.Reader, out io.Writer) (*Token, error) {
+	if cfg.RedirectURI == "" {
+		return nil, errors.New("spotify: headless login requires a redirect URI")
+	}
+
+	state, err := GenerateRandomState()
+	if err != nil {
+		return nil, err
+	}
+	verifier, challenge, err := CreatePKCEVerifierAndChallenge()
+	if err != nil {
+		return nil, err
+	}
+	authURI := BuildPKCEAuthURI(cfg.ClientID, cfg.RedirectURI, challenge, state, cfg.Scopes...)
+
+	fmt.Fprintf(out, "Open the following URL in a browser and approve access:\n\n%s\n\n", authURI)
+	fmt.Fprint(out, "Then paste the URL you were redirected to: ")
+
+	lines := make(chan string, 1)
+	errs := make(chan error, 1)
+	go func() {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			errs <- err
+			return
+		}
+		lines <- strings.TrimSpace(line)
+	}()
+
+	var input string
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case err := <-errs:
+		return nil, fmt.Errorf("spotify: read redirect URL: %w", err)
+	case input = <-lines:
+	}
+
+	code, err := pastedCode(input, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return RequestPKCEToken(ctx, cfg.ClientID, code, cfg.RedirectURI, verifier)
+}
+
+// pastedCode extracts the authorization code from a pasted redirect URL,
+// validating its state. Input without a query is taken as the bare code.
+func pastedCode(input, state string) (string, error) {
+	if input == "" {
+		return "", errors.New("spotify: authorization failed: no redirect URL given")
+	}
+	if !strings.Contains(input, "?") {
+		return input, nil
+	}
+
+	u, err := url.Parse(input)
+	if err != nil {
+		return "", fmt.Errorf("spotify: invalid redirect URL: %w", err)
+	}
+	return callbackCode(u.Query(), state)
+}
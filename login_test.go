@@ -0,0 +1,38 @@
+package spotifyclient
+
+import (
+	"net"
+	"testing"
+)
+
+func TestListenForCallbackLoopbackOnly(t *testing.T) {
+	tests := []struct {
+		redirectURI string
+		wantErr     bool
+	}{
+		{"", false},
+		{"http://127.0.0.1:0/callback", false},
+		{"http://[::1]:0/callback", false},
+		{"http://localhost:0/callback", false},
+		{"http://0.0.0.0:0/callback", true},
+		{"http://[::]:0/callback", true},
+		{"http://example.com:0/callback", true},
+		{"https://127.0.0.1:0/callback", true},
+	}
+
+	for _, tt := range tests {
+		u, listener, err := listenForCallback(tt.redirectURI)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("listenForCallback(%q) err = %v, want error %v", tt.redirectURI, err, tt.wantErr)
+		}
+		if listener == nil {
+			continue
+		}
+		listener.Close()
+
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+		if u.Port() != port {
+			t.Errorf("listenForCallback(%q) = %s, want port %s", tt.redirectURI, u, port)
+		}
+	}
+}
@@ -3,7 +3,7 @@ package spotifyclient
 import "time"
 
 type Meta struct {
-	HREF         HREF
+	HREF         HREF              `json:"href"`
 	ExternalURLs map[string]string `json:"external_urls"`
 	ID           string            `json:"id"`
 	Type         string            `json:"type"`
@@ -11,7 +11,7 @@ type Meta struct {
 }
 
 type PagingMeta struct {
-	HREF     HREF
+	HREF     HREF   `json:"href"`
 	Limit    int    `json:"limit"`
 	Next     string `json:"next"`
 	Offset   int    `json:"offset"`
@@ -19,25 +19,33 @@ type PagingMeta struct {
 	Total    int    `json:"total"`
 }
 
-type AlbumPage struct {
+// Page is one page of an offset-paged collection.
+type Page[T any] struct {
 	PagingMeta
-	Items []*Album `json:"items"`
+	Items []T `json:"items"`
 }
 
-type TrackPage struct {
-	PagingMeta
-	Items []*Track `json:"items"`
+type CursorPagingMeta struct {
+	HREF    HREF    `json:"href"`
+	Limit   int     `json:"limit"`
+	Next    string  `json:"next"`
+	Cursors Cursors `json:"cursors"`
+	Total   int     `json:"total"`
 }
 
-type PlaylistPage struct {
-	PagingMeta
-	Items []*Playlist `json:"items"`
+// CursorPage is one page of a cursor-paged collection.
+type CursorPage[T any] struct {
+	CursorPagingMeta
+	Items []T `json:"items"`
 }
 
-type PlaylistTrackPage struct {
-	PagingMeta
-	Items []*PlaylistTrack `json:"items"`
-}
+type AlbumPage = Page[*Album]
+
+type TrackPage = Page[*Track]
+
+type PlaylistPage = Page[*Playlist]
+
+type PlaylistTrackPage = Page[*PlaylistTrack]
 
 type ExplicitContent struct {
 	FilterEnabled bool `json:"filter_enabled"`
@@ -87,6 +95,13 @@ type Artist struct {
 	Name       string   `json:"name"`
 }
 
+// Cursors represents a CursorObject in the Spotify API.
+// https://developer.spotify.com/documentation/web-api/reference/#object-cursorobject
+type Cursors struct {
+	After  string `json:"after"`
+	Before string `json:"before"`
+}
+
 type Devices struct {
 	Devices []Device `json:"devices"`
 }
@@ -109,6 +124,7 @@ type Device struct {
 type HttpError struct {
 	Status  int    `json:"status"`
 	Message string `json:"message"`
+	Reason  string `json:"reason,omitempty"`
 }
 
 // ExplicitContentSettings represents a ExplicitContentSettingsObject in the Spotify API.
@@ -154,6 +170,23 @@ type Playlist struct {
 	Tracks        PlaylistTrackPage `json:"tracks"`
 }
 
+// PlayHistory represents a PlayHistoryObject in the Spotify API.
+// https://developer.spotify.com/documentation/web-api/reference/#object-playhistoryobject
+type PlayHistory struct {
+	Track    Track            `json:"track"`
+	PlayedAt time.Time        `json:"played_at"`
+	Context  *PlaybackContext `json:"context"`
+}
+
+// PlaybackContext represents a ContextObject in the Spotify API.
+// https://developer.spotify.com/documentation/web-api/reference/#object-contextobject
+type PlaybackContext struct {
+	Type         string            `json:"type"`
+	HREF         HREF              `json:"href"`
+	ExternalURLs map[string]string `json:"external_urls"`
+	URI          string            `json:"uri"`
+}
+
 // PlaylistTrack represents a PlaylistTrackObject in the Spotify API.
 // https://developer.spotify.com/documentation/web-api/reference/#object-playlisttrackobject
 type PlaylistTrack struct {
@@ -195,7 +228,7 @@ type Show struct {
 // https://developer.spotify.com/documentation/web-api/reference/#object-trackobject
 type Track struct {
 	Meta
-	Album            Album             `json:"albumomitempty"`
+	Album            Album             `json:"album"`
 	Artists          []Artist          `json:"artists"`
 	AvailableMarkets []string          `json:"available_markets"`
 	DiscNumber       int               `json:"disc_number"`
@@ -0,0 +1,369 @@
+package spotifyclient
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Iterator walks the items of a paged collection, fetching pages as needed.
+//
+//	it := client.Playlist.ListIter()
+//	for it.Next(ctx) {
+//		fmt.Println(it.Item().Name)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	// fetch returns the items of the next page and whether it was the last.
+	fetch func(ctx context.Context) (items []T, last bool, err error)
+	// total returns the size of the collection, or -1 if it isn't known.
+	total func() int
+	// prefetch, if set, enables concurrent page fetches.
+	prefetch func(workers int)
+
+	items []T
+	item  T
+	last  bool
+	err   error
+}
+
+// Next advances to the next item, fetching the next page when the current
+// one is exhausted. It returns false at the end of the collection, on error
+// or when ctx is done.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	for len(it.items) == 0 {
+		if it.last {
+			return false
+		}
+		if err := ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		items, last, err := it.fetch(ctx)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.items, it.last = items, last
+	}
+
+	it.item, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Item returns the current item.
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Total returns the number of items in the collection as reported by the
+// first page, or -1 if no page was fetched yet or the API doesn't report it.
+func (it *Iterator[T]) Total() int {
+	if it.total == nil {
+		return -1
+	}
+	return it.total()
+}
+
+// Prefetch makes the iterator fetch up to workers pages concurrently once the
+// first page has reported the size of the collection. Items are still
+// returned in order. All requests go through the client's rate limiter, so a
+// 429 pauses every worker. Cursor-paged collections can only be fetched one
+// page after another and ignore it. Call it before the first call to Next.
+func (it *Iterator[T]) Prefetch(workers int) *Iterator[T] {
+	if it.prefetch != nil {
+		it.prefetch(workers)
+	}
+	return it
+}
+
+// Collect reads the remaining items of it. max caps the number of items read;
+// zero or less means no cap.
+func Collect[T any](ctx context.Context, it *Iterator[T], max int) ([]T, error) {
+	var items []T
+	for (max <= 0 || len(items) < max) && it.Next(ctx) {
+		items = append(items, it.Item())
+	}
+	return items, it.Err()
+}
+
+// offsetPager fetches the pages of an offset-paged collection by following
+// their next links, or by computing page offsets when prefetching.
+type offsetPager[T any] struct {
+	// first fetches the first page.
+	first func(ctx context.Context) (*Page[T], error)
+	// get fetches the page at href.
+	get func(ctx context.Context, href HREF) (*Page[T], error)
+
+	started bool
+	next    string
+	total   int
+
+	// Prefetch state. pending holds the in-flight pages in order; they are
+	// fetched with ctx, which cancel ends once the iteration stops.
+	workers    int
+	ctx        context.Context
+	cancel     context.CancelFunc
+	template   *url.URL
+	limit      int
+	nextOffset int
+	pending    []chan pageResult[T]
+}
+
+type pageResult[T any] struct {
+	items []T
+	err   error
+}
+
+func (p *offsetPager[T]) fetch(ctx context.Context) ([]T, bool, error) {
+	if p.template != nil {
+		return p.fetchPrefetched(ctx)
+	}
+
+	var page *Page[T]
+	var err error
+	if !p.started {
+		page, err = p.first(ctx)
+	} else {
+		page, err = p.get(ctx, HREF(p.next))
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !p.started {
+		p.started = true
+		p.total = page.Total
+		if p.workers > 1 && page.Next != "" {
+			if err := p.startPrefetch(ctx, page); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+	p.next = page.Next
+
+	return page.Items, page.Next == "", nil
+}
+
+// startPrefetch prepares fetching the pages after first by offset. The pages
+// are fetched with a context derived from ctx.
+func (p *offsetPager[T]) startPrefetch(ctx context.Context, first *Page[T]) error {
+	template, err := url.Parse(first.Next)
+	if err != nil {
+		return err
+	}
+
+	limit := first.Limit
+	if limit <= 0 {
+		limit = len(first.Items)
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	p.template = template
+	p.limit = limit
+	p.nextOffset = first.Offset + limit
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	return nil
+}
+
+// fetchPrefetched keeps up to workers pages in flight and returns the oldest.
+// The pages still in flight are cancelled once it returns the last page or an
+// error, which ends the iteration.
+func (p *offsetPager[T]) fetchPrefetched(ctx context.Context) (items []T, last bool, err error) {
+	defer func() {
+		if last || err != nil {
+			p.cancel()
+		}
+	}()
+
+	for len(p.pending) < p.workers && p.nextOffset < p.total {
+		p.pending = append(p.pending, p.fetchOffset(p.ctx, p.nextOffset))
+		p.nextOffset += p.limit
+	}
+	if len(p.pending) == 0 {
+		return nil, true, nil
+	}
+
+	var res pageResult[T]
+	select {
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case res = <-p.pending[0]:
+	}
+	p.pending = p.pending[1:]
+	if res.err != nil {
+		return nil, false, res.err
+	}
+
+	return res.items, len(p.pending) == 0 && p.nextOffset >= p.total, nil
+}
+
+// fetchOffset fetches the page at offset in the background.
+func (p *offsetPager[T]) fetchOffset(ctx context.Context, offset int) chan pageResult[T] {
+	u := *p.template
+	query := u.Query()
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(p.limit))
+	u.RawQuery = query.Encode()
+
+	// Buffered so the goroutine can finish even if the iterator is abandoned.
+	ch := make(chan pageResult[T], 1)
+	go func() {
+		var res pageResult[T]
+		page, err := p.get(ctx, HREF(u.String()))
+		if err != nil {
+			res.err = err
+		} else {
+			res.items = page.Items
+		}
+		ch <- res
+	}()
+	return ch
+}
+
+// newOffsetIterator returns an iterator over an offset-paged collection whose
+// first page is fetched by first.
+func newOffsetIterator[T any](c *httpClient, first func(ctx context.Context) (*Page[T], error)) *Iterator[T] {
+	return newWrappedOffsetIterator(first, func(ctx context.Context, href HREF) (*Page[T], error) {
+		page := new(Page[T])
+		err := href.get(ctx, c, page)
+		return page, err
+	})
+}
+
+// newWrappedOffsetIterator is like newOffsetIterator for responses that don't
+// consist of the bare page, like search results; get fetches the page at an
+// href.
+func newWrappedOffsetIterator[T any](first func(ctx context.Context) (*Page[T], error), get func(ctx context.Context, href HREF) (*Page[T], error)) *Iterator[T] {
+	p := &offsetPager[T]{first: first, get: get}
+	return &Iterator[T]{
+		fetch: p.fetch,
+		total: func() int {
+			if !p.started {
+				return -1
+			}
+			return p.total
+		},
+		prefetch: func(workers int) {
+			p.workers = workers
+		},
+	}
+}
+
+// PageIter returns an iterator over the items of page and the pages that
+// follow it, e.g. the tracks embedded in a Playlist returned by Fetch.
+func PageIter[T any](c *Client, page *Page[T]) *Iterator[T] {
+	return newOffsetIterator(c.http, func(ctx context.Context) (*Page[T], error) {
+		return page, nil
+	})
+}
+
+// CursorDirection selects which cursor a cursor-paged iterator follows.
+type CursorDirection int
+
+const (
+	// CursorAfter walks the collection using the after cursor. For followed
+	// artists this is the only direction; for recently played tracks it
+	// walks towards newer items.
+	CursorAfter CursorDirection = iota
+	// CursorBefore walks the collection using the before cursor, e.g.
+	// towards older recently played tracks.
+	CursorBefore
+)
+
+// CursorOptions configures a cursor-paged iterator.
+type CursorOptions struct {
+	// Direction selects the cursor to follow.
+	Direction CursorDirection
+	// Cursor, if set, is where the iteration starts, e.g. the Cursors.After
+	// of an earlier page or a Unix timestamp in milliseconds.
+	Cursor string
+	// Limit is the page size. Zero uses the endpoint's maximum.
+	Limit int
+}
+
+// cursorPager fetches the pages of a cursor-paged collection by passing the
+// cursor of each page to the request for the next one.
+type cursorPager[T any] struct {
+	// get fetches the page for query.
+	get   func(ctx context.Context, query url.Values) (*CursorPage[T], error)
+	query url.Values
+	dir   CursorDirection
+
+	started bool
+	total   int
+}
+
+func (p *cursorPager[T]) fetch(ctx context.Context) ([]T, bool, error) {
+	page, err := p.get(ctx, p.query)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !p.started {
+		p.started = true
+		p.total = page.Total
+	}
+
+	cursor, param := page.Cursors.After, "after"
+	if p.dir == CursorBefore {
+		cursor, param = page.Cursors.Before, "before"
+	}
+
+	query := url.Values{}
+	for k, v := range p.query {
+		if k != "after" && k != "before" {
+			query[k] = v
+		}
+	}
+	query.Set(param, cursor)
+	p.query = query
+
+	return page.Items, cursor == "" || len(page.Items) == 0, nil
+}
+
+// newCursorIterator returns an iterator over a cursor-paged collection whose
+// pages are fetched by get.
+func newCursorIterator[T any](opts *CursorOptions, maxLimit int, get func(ctx context.Context, query url.Values) (*CursorPage[T], error)) *Iterator[T] {
+	if opts == nil {
+		opts = &CursorOptions{}
+	}
+
+	limit := opts.Limit
+	if limit <= 0 || limit > maxLimit {
+		limit = maxLimit
+	}
+	query := url.Values{"limit": {strconv.Itoa(limit)}}
+	if opts.Cursor != "" {
+		if opts.Direction == CursorBefore {
+			query.Set("before", opts.Cursor)
+		} else {
+			query.Set("after", opts.Cursor)
+		}
+	}
+
+	p := &cursorPager[T]{get: get, query: query, dir: opts.Direction}
+	return &Iterator[T]{
+		fetch: p.fetch,
+		total: func() int {
+			if !p.started {
+				return -1
+			}
+			return p.total
+		},
+	}
+}
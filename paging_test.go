@@ -0,0 +1,125 @@
+package spotifyclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// pagedTracks serves a playlist of total items, numbered from 0, taking a
+// random time for each page so prefetched pages complete out of order.
+// Requests for the page at failAt, if not negative, fail.
+func pagedTracks(total, failAt int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if offset == failAt {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+
+		page := map[string]interface{}{"total": total, "limit": limit, "offset": offset}
+		var items []map[string]interface{}
+		for i := offset; i < offset+limit && i < total; i++ {
+			items = append(items, map[string]interface{}{"track": map[string]string{"uri": strconv.Itoa(i)}})
+		}
+		page["items"] = items
+		if offset+limit < total {
+			page["next"] = fmt.Sprintf("http://%s%s?offset=%d&limit=%d", r.Host, r.URL.Path, offset+limit, limit)
+		}
+		json.NewEncoder(w).Encode(page)
+	}
+}
+
+func TestIteratorPrefetch(t *testing.T) {
+	for _, workers := range []int{0, 1, 4, 20} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			c := newTestClient(t, pagedTracks(95, -1))
+
+			it := c.Playlist.Items("p", &PlaylistItemsOptions{Limit: 10}).Prefetch(workers)
+			items, err := Collect(context.Background(), it, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 95 {
+				t.Fatalf("got %d items, want 95", len(items))
+			}
+			for i, item := range items {
+				if item.Track.URI != strconv.Itoa(i) {
+					t.Fatalf("item %d is %q", i, item.Track.URI)
+				}
+			}
+			if it.Total() != 95 {
+				t.Errorf("Total() = %d, want 95", it.Total())
+			}
+		})
+	}
+}
+
+func TestIteratorPrefetchError(t *testing.T) {
+	c := newTestClient(t, pagedTracks(95, 50))
+
+	it := c.Playlist.Items("p", &PlaylistItemsOptions{Limit: 10}).Prefetch(4)
+	items, err := Collect(context.Background(), it, 0)
+	if !IsNotFound(err) {
+		t.Fatalf("err = %v, want a 404", err)
+	}
+	if len(items) != 50 {
+		t.Errorf("got %d items before the error, want 50", len(items))
+	}
+	if it.Next(context.Background()) {
+		t.Error("Next succeeded after an error")
+	}
+}
+
+func TestIteratorPrefetchErrorCancelsPending(t *testing.T) {
+	cancelled := make(chan int, 10)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch offset, _ := strconv.Atoi(r.URL.Query().Get("offset")); {
+		case offset == 20:
+			// Let the later pages get sent before failing.
+			time.Sleep(20 * time.Millisecond)
+			w.WriteHeader(http.StatusNotFound)
+		case offset > 20:
+			select {
+			case <-r.Context().Done():
+				cancelled <- offset
+			case <-time.After(2 * time.Second):
+			}
+		default:
+			pagedTracks(95, -1)(w, r)
+		}
+	})
+
+	it := c.Playlist.Items("p", &PlaylistItemsOptions{Limit: 10}).Prefetch(4)
+	if _, err := Collect(context.Background(), it, 0); !IsNotFound(err) {
+		t.Fatalf("err = %v, want a 404", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("pages in flight weren't cancelled after the error")
+	}
+}
+
+func TestPagingMetaGet(t *testing.T) {
+	c := newTestClient(t, pagedTracks(25, -1))
+
+	// Only the path and query are used, so the request goes to the test
+	// server.
+	meta := PagingMeta{HREF: "https://api.spotify.com/v1/playlists/p/tracks?offset=20&limit=10"}
+	var page PlaylistTrackPage
+	if err := meta.Get(context.Background(), c, &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Offset != 20 || len(page.Items) != 5 {
+		t.Errorf("got offset %d with %d items, want offset 20 with 5", page.Offset, len(page.Items))
+	}
+}
@@ -0,0 +1,132 @@
+package spotifyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+)
+
+// MaxCoverImageSize is the largest base64-encoded cover image Spotify accepts.
+const MaxCoverImageSize = 256 << 10
+
+// coverQualities are the JPEG qualities tried, in order, before downscaling.
+var coverQualities = []int{90, 75, 60}
+
+// CoverImages returns the current cover images of a playlist.
+func (p *PlaylistService) CoverImages(ctx context.Context, id string) ([]Image, error) {
+	var images []Image
+	err := p.client.get(ctx, "v1", fmt.Sprintf("/playlists/%s/images", id), nil, &images)
+	return images, err
+}
+
+// UploadCoverImage sets the cover image of a playlist. The image is encoded
+// as JPEG, lowering the quality and then the size until it fits within
+// MaxCoverImageSize.
+func (p *PlaylistService) UploadCoverImage(ctx context.Context, id string, img image.Image) error {
+	data, err := encodeCover(img)
+	if err != nil {
+		return err
+	}
+	return p.uploadCover(ctx, id, data)
+}
+
+// UploadCoverJPEG sets the cover image of a playlist from JPEG data. Images
+// that don't fit within MaxCoverImageSize are re-encoded as by
+// UploadCoverImage.
+func (p *PlaylistService) UploadCoverJPEG(ctx context.Context, id string, data []byte) error {
+	if base64.StdEncoding.EncodedLen(len(data)) <= MaxCoverImageSize {
+		return p.uploadCover(ctx, id, data)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("spotify: decode cover image: %w", err)
+	}
+	return p.UploadCoverImage(ctx, id, img)
+}
+
+func (p *PlaylistService) uploadCover(ctx context.Context, id string, data []byte) error {
+	if err := p.client.requireScopes(ctx, ScopeUGCImageUpload); err != nil {
+		return err
+	}
+	if err := p.client.requireAnyScope(ctx, ScopePlaylistModifyPublic, ScopePlaylistModifyPrivate); err != nil {
+		return err
+	}
+
+	body := strings.NewReader(base64.StdEncoding.EncodeToString(data))
+	return p.client.putContent(ctx, "v1", fmt.Sprintf("/playlists/%s/images", id), nil, "image/jpeg", body, nil)
+}
+
+// encodeCover encodes img as a JPEG whose base64 encoding fits within
+// MaxCoverImageSize.
+func encodeCover(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		for _, quality := range coverQualities {
+			buf.Reset()
+			if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+				return nil, err
+			}
+			if base64.StdEncoding.EncodedLen(buf.Len()) <= MaxCoverImageSize {
+				return buf.Bytes(), nil
+			}
+		}
+
+		b := img.Bounds()
+		if b.Dx() <= 1 || b.Dy() <= 1 {
+			return nil, errors.New("spotify: cover image can't be made small enough")
+		}
+		img = downscale(img, b.Dx()*3/4, b.Dy()*3/4)
+	}
+}
+
+// downscale resizes img to w×h by averaging the source pixels covered by
+// each destination pixel.
+func downscale(img image.Image, w, h int) image.Image {
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	src := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := src.Min.Y + y*src.Dy()/h
+		y1 := src.Min.Y + (y+1)*src.Dy()/h
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < w; x++ {
+			x0 := src.Min.X + x*src.Dx()/w
+			x1 := src.Min.X + (x+1)*src.Dx()/w
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
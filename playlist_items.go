@@ -0,0 +1,310 @@
+package spotifyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+// MaxItemsPerRequest is the most items Spotify accepts in a single add,
+// remove or replace request.
+const MaxItemsPerRequest = 100
+
+// PlaylistItemsOptions configures PlaylistService.Items.
+type PlaylistItemsOptions struct {
+	// Fields filters the returned fields, e.g. "items(added_at,track(name,uri))".
+	// The paging fields are always requested.
+	Fields string
+	// Market is an ISO 3166-1 alpha-2 country code used for track relinking.
+	Market string
+	// Limit is the page size. Zero uses the maximum of 100.
+	Limit int
+}
+
+// PlaylistItem identifies an item to remove from a playlist.
+type PlaylistItem struct {
+	URI string `json:"uri"`
+	// Positions, if set, restricts the removal to the occurrences at these
+	// zero-based positions.
+	Positions []int `json:"positions,omitempty"`
+}
+
+type snapshotResponse struct {
+	SnapshotID string `json:"snapshot_id"`
+}
+
+// Items returns an iterator over the items of a playlist.
+func (p *PlaylistService) Items(id string, opts *PlaylistItemsOptions) *Iterator[*PlaylistTrack] {
+	if opts == nil {
+		opts = &PlaylistItemsOptions{}
+	}
+
+	query := make(url.Values)
+	limit := opts.Limit
+	if limit <= 0 || limit > MaxItemsPerRequest {
+		limit = MaxItemsPerRequest
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	if opts.Fields != "" {
+		query.Set("fields", opts.Fields+",href,limit,next,offset,previous,total")
+	}
+	if opts.Market != "" {
+		query.Set("market", opts.Market)
+	}
+
+	return newOffsetIterator(p.client, func(ctx context.Context) (*PlaylistTrackPage, error) {
+		page := new(PlaylistTrackPage)
+		err := p.client.get(ctx, "v1", fmt.Sprintf("/playlists/%s/tracks", id), query, page)
+		return page, err
+	})
+}
+
+// AddItems inserts up to MaxItemsPerRequest URIs at position, or appends
+// them when position is negative. It returns the new snapshot ID.
+func (p *PlaylistService) AddItems(ctx context.Context, id string, uris []string, position int) (string, error) {
+	if err := checkItemCount(len(uris)); err != nil {
+		return "", err
+	}
+
+	body := &struct {
+		URIs     []string `json:"uris"`
+		Position *int     `json:"position,omitempty"`
+	}{
+		URIs: uris,
+	}
+	if position >= 0 {
+		body.Position = &position
+	}
+
+	return p.modifyItems(ctx, p.client.post, id, body)
+}
+
+// RemoveItems removes up to MaxItemsPerRequest items. A non-empty snapshotID
+// makes positions refer to that version of the playlist. It returns the new
+// snapshot ID.
+func (p *PlaylistService) RemoveItems(ctx context.Context, id string, items []PlaylistItem, snapshotID string) (string, error) {
+	if err := checkItemCount(len(items)); err != nil {
+		return "", err
+	}
+
+	body := &struct {
+		Tracks     []PlaylistItem `json:"tracks"`
+		SnapshotID string         `json:"snapshot_id,omitempty"`
+	}{
+		Tracks:     items,
+		SnapshotID: snapshotID,
+	}
+
+	// Removing every occurrence of a URI can be repeated, but positions
+	// shift once the first attempt is applied.
+	send := p.client.delete
+	if hasPositions(items) {
+		send = p.client.deleteOnce
+	}
+	return p.modifyItems(ctx, send, id, body)
+}
+
+func hasPositions(items []PlaylistItem) bool {
+	for _, item := range items {
+		if len(item.Positions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ReorderItems moves rangeLength items starting at rangeStart so that they
+// are inserted before the item at insertBefore. A non-empty snapshotID makes
+// the positions refer to that version of the playlist. The request is never
+// retried, since a failed attempt may already have moved the items. It
+// returns the new snapshot ID.
+func (p *PlaylistService) ReorderItems(ctx context.Context, id string, rangeStart, insertBefore, rangeLength int, snapshotID string) (string, error) {
+	body := &struct {
+		RangeStart   int    `json:"range_start"`
+		InsertBefore int    `json:"insert_before"`
+		RangeLength  int    `json:"range_length"`
+		SnapshotID   string `json:"snapshot_id,omitempty"`
+	}{
+		RangeStart:   rangeStart,
+		InsertBefore: insertBefore,
+		RangeLength:  rangeLength,
+		SnapshotID:   snapshotID,
+	}
+
+	return p.modifyItems(ctx, p.client.putOnce, id, body)
+}
+
+// ReplaceItems replaces every item of the playlist with up to
+// MaxItemsPerRequest URIs. An empty uris clears the playlist. It returns the
+// new snapshot ID.
+func (p *PlaylistService) ReplaceItems(ctx context.Context, id string, uris []string) (string, error) {
+	if err := checkItemCount(len(uris)); err != nil {
+		return "", err
+	}
+	if uris == nil {
+		uris = []string{}
+	}
+
+	body := &struct {
+		URIs []string `json:"uris"`
+	}{
+		URIs: uris,
+	}
+
+	return p.modifyItems(ctx, p.client.put, id, body)
+}
+
+// modifyItems sends body to the playlist items endpoint with send and returns
+// the resulting snapshot ID.
+func (p *PlaylistService) modifyItems(ctx context.Context, send func(context.Context, string, string, url.Values, io.Reader, interface{}) error, id string, body interface{}) (string, error) {
+	if err := p.client.requireAnyScope(ctx, ScopePlaylistModifyPublic, ScopePlaylistModifyPrivate); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	res := new(snapshotResponse)
+	err = send(ctx, "v1", fmt.Sprintf("/playlists/%s/tracks", id), nil, bytes.NewReader(data), res)
+	return res.SnapshotID, err
+}
+
+func checkItemCount(n int) error {
+	if n > MaxItemsPerRequest {
+		return fmt.Errorf("spotify: at most %d items per request, got %d", MaxItemsPerRequest, n)
+	}
+	return nil
+}
+
+// BulkOptions configures the bulk playlist item operations.
+type BulkOptions struct {
+	// StartChunk skips the chunks before it, to resume an operation that
+	// failed with a BulkError.
+	StartChunk int
+	// Progress, if set, is called after each chunk is applied.
+	Progress func(BulkProgress)
+}
+
+// BulkProgress reports how far a bulk operation got.
+type BulkProgress struct {
+	// Chunk is the index of the last applied chunk and Chunks their count.
+	Chunk  int
+	Chunks int
+	// Applied is the number of items applied so far, including skipped
+	// chunks, out of Total.
+	Applied int
+	Total   int
+	// SnapshotID is the playlist snapshot after the last applied chunk.
+	SnapshotID string
+}
+
+// BulkError is returned when a chunk of a bulk operation fails. The chunks
+// before it were applied.
+type BulkError struct {
+	// Chunk is the index of the failed chunk. Pass it as
+	// BulkOptions.StartChunk to resume.
+	Chunk int
+	// Applied is the number of items applied before the failure.
+	Applied int
+	// SnapshotID is the playlist snapshot after the last applied chunk.
+	SnapshotID string
+	Err        error
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("spotify: bulk operation failed at chunk %d after %d items: %v", e.Chunk, e.Applied, e.Err)
+}
+
+func (e *BulkError) Unwrap() error {
+	return e.Err
+}
+
+// AddItemsBulk inserts any number of URIs at position, or appends them when
+// position is negative, in chunks of MaxItemsPerRequest. It returns the final
+// snapshot ID.
+func (p *PlaylistService) AddItemsBulk(ctx context.Context, id string, uris []string, position int, opts *BulkOptions) (string, error) {
+	return runBulk(len(uris), opts, func(chunk, start, end int) (string, error) {
+		pos := position
+		if pos >= 0 {
+			pos += start
+		}
+		return p.AddItems(ctx, id, uris[start:end], pos)
+	})
+}
+
+// RemoveItemsBulk removes any number of items in chunks of
+// MaxItemsPerRequest. Every chunk is applied against snapshotID so positions
+// keep referring to that version of the playlist. When snapshotID is empty
+// and the items have positions spanning several chunks, the current snapshot
+// is fetched first, since each chunk shifts the items after it. It returns
+// the final snapshot ID.
+func (p *PlaylistService) RemoveItemsBulk(ctx context.Context, id string, items []PlaylistItem, snapshotID string, opts *BulkOptions) (string, error) {
+	if snapshotID == "" && len(items) > MaxItemsPerRequest && hasPositions(items) {
+		var err error
+		if snapshotID, err = p.snapshotID(ctx, id); err != nil {
+			return "", err
+		}
+	}
+
+	return runBulk(len(items), opts, func(chunk, start, end int) (string, error) {
+		return p.RemoveItems(ctx, id, items[start:end], snapshotID)
+	})
+}
+
+// ReplaceItemsBulk replaces every item of the playlist with any number of
+// URIs: the first chunk replaces the items and the rest are appended. It
+// returns the final snapshot ID.
+func (p *PlaylistService) ReplaceItemsBulk(ctx context.Context, id string, uris []string, opts *BulkOptions) (string, error) {
+	if len(uris) == 0 {
+		return p.ReplaceItems(ctx, id, nil)
+	}
+
+	return runBulk(len(uris), opts, func(chunk, start, end int) (string, error) {
+		if chunk == 0 {
+			return p.ReplaceItems(ctx, id, uris[start:end])
+		}
+		return p.AddItems(ctx, id, uris[start:end], -1)
+	})
+}
+
+// runBulk calls apply for each chunk of n items, in order, starting at
+// opts.StartChunk.
+func runBulk(n int, opts *BulkOptions, apply func(chunk, start, end int) (string, error)) (string, error) {
+	if opts == nil {
+		opts = &BulkOptions{}
+	}
+
+	chunks := (n + MaxItemsPerRequest - 1) / MaxItemsPerRequest
+	var snapshotID string
+	for chunk := opts.StartChunk; chunk < chunks; chunk++ {
+		start := chunk * MaxItemsPerRequest
+		end := start + MaxItemsPerRequest
+		if end > n {
+			end = n
+		}
+
+		id, err := apply(chunk, start, end)
+		if err != nil {
+			return snapshotID, &BulkError{Chunk: chunk, Applied: start, SnapshotID: snapshotID, Err: err}
+		}
+		snapshotID = id
+
+		if opts.Progress != nil {
+			opts.Progress(BulkProgress{
+				Chunk:      chunk,
+				Chunks:     chunks,
+				Applied:    end,
+				Total:      n,
+				SnapshotID: snapshotID,
+			})
+		}
+	}
+
+	return snapshotID, nil
+}
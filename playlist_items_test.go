@@ -0,0 +1,158 @@
+package spotifyclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// bulkRequest is a playlist items request seen by bulkServer.
+type bulkRequest struct {
+	Method     string
+	URIs       []string       `json:"uris"`
+	Position   *int           `json:"position"`
+	Tracks     []PlaylistItem `json:"tracks"`
+	SnapshotID string         `json:"snapshot_id"`
+}
+
+// bulkServer records the playlist items requests it gets, answering each
+// with a new snapshot ID, and fails the request numbered failAt.
+type bulkServer struct {
+	mu       sync.Mutex
+	requests []bulkRequest
+	failAt   int
+}
+
+func (s *bulkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/v1/playlists/p" {
+		w.Write([]byte(`{"snapshot_id":"current"}`))
+		return
+	}
+
+	req := bulkRequest{Method: r.Method}
+	json.NewDecoder(r.Body).Decode(&req)
+	s.requests = append(s.requests, req)
+	if len(s.requests) == s.failAt {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(w, `{"snapshot_id":"snap%d"}`, len(s.requests))
+}
+
+func trackURIs(n int) []string {
+	uris := make([]string, n)
+	for i := range uris {
+		uris[i] = fmt.Sprintf("spotify:track:%d", i)
+	}
+	return uris
+}
+
+func TestAddItemsBulk(t *testing.T) {
+	srv := &bulkServer{}
+	c := newTestClient(t, srv.ServeHTTP)
+
+	var progress []BulkProgress
+	uris := trackURIs(250)
+	snapshotID, err := c.Playlist.AddItemsBulk(context.Background(), "p", uris, 10, &BulkOptions{
+		Progress: func(p BulkProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshotID != "snap3" {
+		t.Errorf("snapshot ID = %q, want snap3", snapshotID)
+	}
+
+	for i, req := range srv.requests {
+		start := i * MaxItemsPerRequest
+		end := start + MaxItemsPerRequest
+		if end > len(uris) {
+			end = len(uris)
+		}
+		if !reflect.DeepEqual(req.URIs, uris[start:end]) {
+			t.Errorf("chunk %d: sent %d URIs from %q, want %d from %q", i, len(req.URIs), req.URIs[0], end-start, uris[start])
+		}
+		if req.Position == nil || *req.Position != 10+start {
+			t.Errorf("chunk %d: position %v, want %d", i, req.Position, 10+start)
+		}
+	}
+
+	want := []BulkProgress{
+		{Chunk: 0, Chunks: 3, Applied: 100, Total: 250, SnapshotID: "snap1"},
+		{Chunk: 1, Chunks: 3, Applied: 200, Total: 250, SnapshotID: "snap2"},
+		{Chunk: 2, Chunks: 3, Applied: 250, Total: 250, SnapshotID: "snap3"},
+	}
+	if !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %+v, want %+v", progress, want)
+	}
+}
+
+func TestAddItemsBulkResume(t *testing.T) {
+	srv := &bulkServer{failAt: 2}
+	c := newTestClient(t, srv.ServeHTTP)
+	uris := trackURIs(250)
+
+	_, err := c.Playlist.AddItemsBulk(context.Background(), "p", uris, -1, nil)
+	var bulkErr *BulkError
+	if !errors.As(err, &bulkErr) {
+		t.Fatalf("err = %v, want a BulkError", err)
+	}
+	if bulkErr.Chunk != 1 || bulkErr.Applied != 100 || bulkErr.SnapshotID != "snap1" {
+		t.Errorf("BulkError = %+v, want chunk 1 after 100 items at snap1", bulkErr)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("err = %v, want it to wrap the 400", err)
+	}
+
+	srv.requests, srv.failAt = nil, 0
+	if _, err := c.Playlist.AddItemsBulk(context.Background(), "p", uris, -1, &BulkOptions{StartChunk: bulkErr.Chunk}); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.requests) != 2 || srv.requests[0].URIs[0] != uris[100] || srv.requests[0].Position != nil {
+		t.Errorf("resumed with %+v, want 2 appending chunks from item 100", srv.requests)
+	}
+}
+
+func TestRemoveItemsBulkPositions(t *testing.T) {
+	items := make([]PlaylistItem, 250)
+	for i := range items {
+		items[i] = PlaylistItem{URI: "spotify:track:a", Positions: []int{i}}
+	}
+
+	tests := []struct {
+		name       string
+		items      []PlaylistItem
+		snapshotID string
+		want       string
+	}{
+		{name: "given snapshot", items: items, snapshotID: "given", want: "given"},
+		{name: "fetched snapshot", items: items, want: "current"},
+		{name: "single chunk", items: items[:10], want: ""},
+		{name: "by URI", items: []PlaylistItem{{URI: "spotify:track:a"}}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &bulkServer{}
+			c := newTestClient(t, srv.ServeHTTP)
+
+			if _, err := c.Playlist.RemoveItemsBulk(context.Background(), "p", tt.items, tt.snapshotID, nil); err != nil {
+				t.Fatal(err)
+			}
+			for i, req := range srv.requests {
+				if req.SnapshotID != tt.want {
+					t.Errorf("chunk %d sent snapshot %q, want %q", i, req.SnapshotID, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package spotifyclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// ErrSnapshotConflict is returned by Sync when the playlist changed between
+// reading it and applying the changes.
+var ErrSnapshotConflict = errors.New("spotify: playlist changed during sync")
+
+// SyncOptions configures PlaylistService.Sync.
+type SyncOptions struct {
+	// DryRun computes the changes without applying them.
+	DryRun bool
+}
+
+// SyncMove is a reorder applied by Sync, in the terms of ReorderItems. URIs
+// lists the moved items, with "" for skipped ones.
+type SyncMove struct {
+	URIs         []string
+	RangeStart   int
+	InsertBefore int
+	RangeLength  int
+}
+
+// SyncAdd is an insertion applied by Sync, in the terms of AddItems.
+type SyncAdd struct {
+	Position int
+	URIs     []string
+}
+
+// SyncReport describes the changes Sync applied, or would apply in a dry run.
+// Removals refer to positions in the original playlist and are applied
+// first, then moves and then additions, each in order.
+type SyncReport struct {
+	// SnapshotID is the snapshot the changes were computed against, or the
+	// resulting snapshot once they are applied.
+	SnapshotID string
+	Removes    []PlaylistItem
+	Moves      []SyncMove
+	Adds       []SyncAdd
+	// Skipped holds the positions in the original playlist of the items
+	// that can't be referred to by URI, i.e. unavailable tracks and local
+	// files. They are left in the playlist, next to the item they followed.
+	Skipped []int
+	// Applied is set when the changes were made.
+	Applied bool
+}
+
+// Empty reports whether the playlist already matched.
+func (r *SyncReport) Empty() bool {
+	return len(r.Removes) == 0 && len(r.Moves) == 0 && len(r.Adds) == 0
+}
+
+// Sync makes the items of a playlist equal to the ordered list of URIs in
+// desired, using as few removals, moves and additions as it can.
+//
+// It fails with ErrSnapshotConflict if the playlist is modified while it is
+// read, or between the removals, moves and additions. Spotify can't make a
+// write conditional on a snapshot, so a change made by someone else while
+// one of these phases runs goes unnoticed, and the playlist may be left
+// partly synced when a check fails.
+func (p *PlaylistService) Sync(ctx context.Context, id string, desired []string, opts *SyncOptions) (*SyncReport, error) {
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+
+	snapshotID, err := p.snapshotID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := p.itemURIs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkSnapshot(ctx, id, snapshotID); err != nil {
+		return nil, err
+	}
+
+	report := planSync(current, desired)
+	report.SnapshotID = snapshotID
+	if opts.DryRun || report.Empty() {
+		return report, nil
+	}
+
+	if len(report.Removes) > 0 {
+		// Positions refer to the snapshot the plan was computed against.
+		if snapshotID, err = p.RemoveItemsBulk(ctx, id, report.Removes, report.SnapshotID, nil); err != nil {
+			return nil, err
+		}
+	}
+	if len(report.Moves) > 0 && len(report.Removes) > 0 {
+		if err := p.checkSnapshot(ctx, id, snapshotID); err != nil {
+			return nil, err
+		}
+	}
+	for _, move := range report.Moves {
+		if snapshotID, err = p.ReorderItems(ctx, id, move.RangeStart, move.InsertBefore, move.RangeLength, snapshotID); err != nil {
+			return nil, err
+		}
+	}
+	if len(report.Adds) > 0 && (len(report.Removes) > 0 || len(report.Moves) > 0) {
+		if err := p.checkSnapshot(ctx, id, snapshotID); err != nil {
+			return nil, err
+		}
+	}
+	for _, add := range report.Adds {
+		if snapshotID, err = p.AddItemsBulk(ctx, id, add.URIs, add.Position, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	report.SnapshotID = snapshotID
+	report.Applied = true
+	return report, nil
+}
+
+func (p *PlaylistService) snapshotID(ctx context.Context, id string) (string, error) {
+	res := new(snapshotResponse)
+	err := p.client.get(ctx, "v1", fmt.Sprintf("/playlists/%s", id), url.Values{"fields": {"snapshot_id"}}, res)
+	return res.SnapshotID, err
+}
+
+func (p *PlaylistService) checkSnapshot(ctx context.Context, id, snapshotID string) error {
+	current, err := p.snapshotID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current != snapshotID {
+		return ErrSnapshotConflict
+	}
+	return nil
+}
+
+// itemURIs lists the URIs of the items of a playlist, with "" for the items
+// Sync can't address.
+func (p *PlaylistService) itemURIs(ctx context.Context, id string) ([]string, error) {
+	it := p.Items(id, &PlaylistItemsOptions{Fields: "items(is_local,track(uri))"})
+
+	var uris []string
+	for it.Next(ctx) {
+		item := it.Item()
+		// Unavailable tracks come back as null, and local files can't be
+		// removed or added through the API.
+		if item.IsLocal {
+			uris = append(uris, "")
+		} else {
+			uris = append(uris, item.Track.URI)
+		}
+	}
+	return uris, it.Err()
+}
+
+// planSync computes the changes turning current into desired. Items of
+// current with an empty URI can't be addressed and are skipped.
+//
+// Surplus occurrences are removed first. Every remaining item is then matched
+// to an occurrence of its URI in desired; the longest run of matched items
+// already in the right relative order stays put and the others are moved.
+// Whatever desired still lacks is added last.
+func planSync(current, desired []string) *SyncReport {
+	report := &SyncReport{}
+
+	need := make(map[string]int)
+	for _, uri := range desired {
+		need[uri]++
+	}
+
+	// Keep the first occurrences of each URI, remove the rest.
+	var kept []string
+	removes := make(map[string][]int)
+	var removeOrder []string
+	for pos, uri := range current {
+		if uri == "" {
+			report.Skipped = append(report.Skipped, pos)
+			kept = append(kept, uri)
+			continue
+		}
+		if need[uri] > 0 {
+			need[uri]--
+			kept = append(kept, uri)
+			continue
+		}
+		if _, ok := removes[uri]; !ok {
+			removeOrder = append(removeOrder, uri)
+		}
+		removes[uri] = append(removes[uri], pos)
+	}
+	for _, uri := range removeOrder {
+		report.Removes = append(report.Removes, PlaylistItem{URI: uri, Positions: removes[uri]})
+	}
+
+	// Match the n-th kept occurrence of a URI to its n-th desired occurrence.
+	occurrences := make(map[string][]int)
+	for i, uri := range desired {
+		occurrences[uri] = append(occurrences[uri], i)
+	}
+	targets := make([]int, len(kept))
+	for i, uri := range kept {
+		if uri != "" {
+			targets[i] = occurrences[uri][0]
+			occurrences[uri] = occurrences[uri][1:]
+		}
+	}
+
+	// The skipped items stay in the result, so they are woven into desired
+	// right after the item they follow in current, and targets are
+	// renumbered to index that extended list.
+	desired, targets = weaveSkipped(desired, kept, targets)
+	matched := make([]bool, len(desired))
+	for _, t := range targets {
+		matched[t] = true
+	}
+
+	// Items outside the longest increasing run of targets get moved.
+	stays := longestIncreasing(targets)
+	var moved []int
+	for i, t := range targets {
+		if !stays[i] {
+			moved = append(moved, t)
+		}
+	}
+	sort.Ints(moved)
+
+	// Place the moved items in target order right after the settled item
+	// with the largest smaller target, simulating each move on targets.
+	settled := make(map[int]bool)
+	for i, t := range targets {
+		if stays[i] {
+			settled[t] = true
+		}
+	}
+	for k := 0; k < len(moved); {
+		t := moved[k]
+		from, after := -1, -1
+		for i, other := range targets {
+			if other == t {
+				from = i
+			} else if settled[other] && other < t && (after < 0 || other > targets[after]) {
+				after = i
+			}
+		}
+
+		// Items that follow t both in desired and in the playlist move with
+		// it in a single request.
+		n := 1
+		for k+n < len(moved) && moved[k+n] == t+n && from+n < len(targets) && targets[from+n] == t+n {
+			n++
+		}
+
+		// The run may already be in place thanks to earlier moves.
+		insertBefore := after + 1
+		if insertBefore != from && insertBefore != from+n {
+			report.Moves = append(report.Moves, SyncMove{
+				URIs:         append([]string(nil), desired[t:t+n]...),
+				RangeStart:   from,
+				InsertBefore: insertBefore,
+				RangeLength:  n,
+			})
+		}
+
+		run := append([]int(nil), targets[from:from+n]...)
+		targets = append(targets[:from], targets[from+n:]...)
+		if from < insertBefore {
+			insertBefore -= n
+		}
+		targets = append(targets[:insertBefore], append(run, targets[insertBefore:]...)...)
+		for _, t := range run {
+			settled[t] = true
+		}
+		k += n
+	}
+
+	// The existing items are now in desired order, so each missing run can
+	// be inserted at its index in desired.
+	for i := 0; i < len(desired); i++ {
+		if matched[i] {
+			continue
+		}
+		add := SyncAdd{Position: i}
+		for ; i < len(desired) && !matched[i]; i++ {
+			add.URIs = append(add.URIs, desired[i])
+		}
+		report.Adds = append(report.Adds, add)
+	}
+
+	return report
+}
+
+// weaveSkipped inserts the skipped items of kept, those with an empty URI,
+// into desired after the target of the matched item before them. It returns
+// the extended list and the targets of every kept item in it.
+func weaveSkipped(desired, kept []string, targets []int) ([]string, []int) {
+	if len(kept) == 0 {
+		return desired, targets
+	}
+
+	// after[t+1] counts the skipped items following the one matched to t.
+	after := make([]int, len(desired)+1)
+	anchor := -1
+	for i, uri := range kept {
+		if uri == "" {
+			after[anchor+1]++
+		} else {
+			anchor = targets[i]
+		}
+	}
+
+	// index maps positions in desired to the extended list.
+	extended := make([]string, 0, len(desired)+len(kept))
+	index := make([]int, len(desired))
+	extended = append(extended, make([]string, after[0])...)
+	for i, uri := range desired {
+		index[i] = len(extended)
+		extended = append(extended, uri)
+		extended = append(extended, make([]string, after[i+1])...)
+	}
+
+	woven := make([]int, len(targets))
+	next := make([]int, len(desired)+1)
+	anchor = -1
+	for i, uri := range kept {
+		if uri != "" {
+			anchor = targets[i]
+			woven[i] = index[anchor]
+			continue
+		}
+		// Skipped items go in order into the slots after their anchor.
+		start := 0
+		if anchor >= 0 {
+			start = index[anchor] + 1
+		}
+		woven[i] = start + next[anchor+1]
+		next[anchor+1]++
+	}
+
+	return extended, woven
+}
+
+// longestIncreasing marks the elements of a longest strictly increasing
+// subsequence of values.
+func longestIncreasing(values []int) []bool {
+	// tails[k] is the index of the smallest tail of an increasing
+	// subsequence of length k+1; prev links each index to its predecessor.
+	var tails []int
+	prev := make([]int, len(values))
+	for i, v := range values {
+		k := sort.Search(len(tails), func(k int) bool { return values[tails[k]] >= v })
+		if k > 0 {
+			prev[i] = tails[k-1]
+		} else {
+			prev[i] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	in := make([]bool, len(values))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			in[i] = true
+		}
+	}
+	return in
+}
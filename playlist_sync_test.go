@@ -0,0 +1,179 @@
+package spotifyclient
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestPlanSync(t *testing.T) {
+	tests := []struct {
+		name    string
+		current []string
+		desired []string
+		want    *SyncReport
+	}{
+		{
+			name:    "unchanged",
+			current: []string{"a", "b", "c"},
+			desired: []string{"a", "b", "c"},
+			want:    &SyncReport{},
+		},
+		{
+			name:    "surplus occurrences",
+			current: []string{"a", "b", "a", "c"},
+			desired: []string{"a", "c"},
+			want: &SyncReport{
+				Removes: []PlaylistItem{{URI: "b", Positions: []int{1}}, {URI: "a", Positions: []int{2}}},
+			},
+		},
+		{
+			name:    "missing items",
+			current: []string{"a", "c"},
+			desired: []string{"a", "b", "c", "d"},
+			want: &SyncReport{
+				Adds: []SyncAdd{{Position: 1, URIs: []string{"b"}}, {Position: 3, URIs: []string{"d"}}},
+			},
+		},
+		{
+			name:    "block moved as one range",
+			current: []string{"a", "b", "c", "d", "e"},
+			desired: []string{"d", "e", "a", "b", "c"},
+			want: &SyncReport{
+				Moves: []SyncMove{{URIs: []string{"d", "e"}, RangeStart: 3, InsertBefore: 0, RangeLength: 2}},
+			},
+		},
+		{
+			name:    "reversed",
+			current: []string{"a", "b", "c"},
+			desired: []string{"c", "b", "a"},
+			want: &SyncReport{
+				Moves: []SyncMove{
+					{URIs: []string{"b"}, RangeStart: 1, InsertBefore: 3, RangeLength: 1},
+					{URIs: []string{"a"}, RangeStart: 0, InsertBefore: 3, RangeLength: 1},
+				},
+			},
+		},
+		{
+			name:    "unavailable item kept after its predecessor",
+			current: []string{"a", "", "b"},
+			desired: []string{"b", "a"},
+			want: &SyncReport{
+				Moves:   []SyncMove{{URIs: []string{"b"}, RangeStart: 2, InsertBefore: 0, RangeLength: 1}},
+				Skipped: []int{1},
+			},
+		},
+		{
+			name:    "empty playlist",
+			current: nil,
+			desired: []string{"a", "b"},
+			want: &SyncReport{
+				Adds: []SyncAdd{{Position: 0, URIs: []string{"a", "b"}}},
+			},
+		},
+		{
+			name:    "cleared playlist",
+			current: []string{"a", "b"},
+			desired: nil,
+			want: &SyncReport{
+				Removes: []PlaylistItem{{URI: "a", Positions: []int{0}}, {URI: "b", Positions: []int{1}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planSync(tt.current, tt.desired)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planSync(%q, %q) = %+v, want %+v", tt.current, tt.desired, got, tt.want)
+			}
+			checkPlan(t, tt.current, tt.desired, got)
+		})
+	}
+}
+
+func TestPlanSyncRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	list := func(n int) []string {
+		uris := make([]string, rng.Intn(n))
+		for i := range uris {
+			uris[i] = string(rune('a' + rng.Intn(6)))
+		}
+		return uris
+	}
+
+	for i := 0; i < 20000; i++ {
+		current, desired := list(15), list(15)
+		for j := range current {
+			if rng.Intn(8) == 0 {
+				current[j] = ""
+			}
+		}
+		checkPlan(t, current, desired, planSync(current, desired))
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+// checkPlan applies report to current the way Spotify would and checks that
+// the result is desired, with the skipped items still in it.
+func checkPlan(t *testing.T, current, desired []string, report *SyncReport) {
+	t.Helper()
+
+	removed := make(map[int]bool)
+	for _, item := range report.Removes {
+		for _, pos := range item.Positions {
+			if current[pos] != item.URI {
+				t.Fatalf("%q -> %q: removal of %q at %d, found %q", current, desired, item.URI, pos, current[pos])
+			}
+			removed[pos] = true
+		}
+	}
+	var items []string
+	for pos, uri := range current {
+		if !removed[pos] {
+			items = append(items, uri)
+		}
+	}
+
+	for _, move := range report.Moves {
+		end := move.RangeStart + move.RangeLength
+		if move.RangeLength < 1 || end > len(items) || move.InsertBefore > len(items) {
+			t.Fatalf("%q -> %q: move %+v out of range of %q", current, desired, move, items)
+		}
+		run := append([]string(nil), items[move.RangeStart:end]...)
+		if !reflect.DeepEqual(run, move.URIs) {
+			t.Fatalf("%q -> %q: move %+v, found %q", current, desired, move, run)
+		}
+		items = append(items[:move.RangeStart], items[end:]...)
+		insertBefore := move.InsertBefore
+		if move.RangeStart < insertBefore {
+			insertBefore -= move.RangeLength
+		}
+		items = append(items[:insertBefore], append(run, items[insertBefore:]...)...)
+	}
+
+	for _, add := range report.Adds {
+		if add.Position > len(items) {
+			t.Fatalf("%q -> %q: add %+v out of range of %q", current, desired, add, items)
+		}
+		items = append(items[:add.Position], append(append([]string(nil), add.URIs...), items[add.Position:]...)...)
+	}
+
+	var got []string
+	skipped := 0
+	for _, uri := range items {
+		if uri == "" {
+			skipped++
+		} else {
+			got = append(got, uri)
+		}
+	}
+	if len(got) != len(desired) || (len(got) > 0 && !reflect.DeepEqual(got, desired)) {
+		t.Fatalf("%q -> %q: plan %+v gives %q", current, desired, report, items)
+	}
+	if skipped != len(report.Skipped) {
+		t.Fatalf("%q -> %q: %d skipped items left, want %d", current, desired, skipped, len(report.Skipped))
+	}
+}
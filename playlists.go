@@ -2,25 +2,63 @@ package spotifyclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"strings"
 )
 
 type PlaylistService service
 
-func (p *PlaylistService) List() ([]*Playlist, error) {
-	playlistPage := &struct {
-		PagingMeta
-		Items []*Playlist `json:"items"`
-	}{}
+// List returns every playlist owned or followed by the current user.
+func (p *PlaylistService) List(ctx context.Context) ([]*Playlist, error) {
+	return Collect(ctx, p.ListIter(), 0)
+}
+
+// ListIter returns an iterator over the playlists owned or followed by the
+// current user.
+func (p *PlaylistService) ListIter() *Iterator[*Playlist] {
+	return newOffsetIterator(p.client, func(ctx context.Context) (*PlaylistPage, error) {
+		if err := p.client.requireScopes(ctx, ScopePlaylistReadPrivate); err != nil {
+			return nil, err
+		}
 
-	// TODO: Iterate over all pages of playlists
-	err := p.client.get("v1", "/me/playlists", nil, playlistPage)
-	return playlistPage.Items, err
+		page := new(PlaylistPage)
+		err := p.client.get(ctx, "v1", "/me/playlists", url.Values{"limit": {"50"}}, page)
+		return page, err
+	})
 }
 
-func (p *PlaylistService) Create(userID, name string, public, collaborative bool, description string) (*Playlist, error) {
+// ListUser returns every public playlist of the given user.
+func (p *PlaylistService) ListUser(ctx context.Context, userID string) ([]*Playlist, error) {
+	return Collect(ctx, p.ListUserIter(userID), 0)
+}
+
+// ListUserIter returns an iterator over the public playlists of the given
+// user.
+func (p *PlaylistService) ListUserIter(userID string) *Iterator[*Playlist] {
+	return newOffsetIterator(p.client, func(ctx context.Context) (*PlaylistPage, error) {
+		page := new(PlaylistPage)
+		err := p.client.get(ctx, "v1", fmt.Sprintf("/users/%s/playlists", userID), url.Values{"limit": {"50"}}, page)
+		return page, err
+	})
+}
+
+func (p *PlaylistService) Create(ctx context.Context, userID, name string, public, collaborative bool, description string) (*Playlist, error) {
+	if collaborative && public {
+		return nil, errors.New("spotify: collaborative playlists must be private")
+	}
+
+	scope := ScopePlaylistModifyPrivate
+	if public {
+		scope = ScopePlaylistModifyPublic
+	}
+	if err := p.client.requireScopes(ctx, scope); err != nil {
+		return nil, err
+	}
+
 	query := make(url.Values)
 	query.Add("user_id", userID)
 
@@ -42,35 +80,141 @@ func (p *PlaylistService) Create(userID, name string, public, collaborative bool
 	}
 
 	playlist := new(Playlist)
-	err = p.client.post("v1", fmt.Sprintf("/users/%s/playlists", userID), query, bytes.NewReader(data), playlist)
+	err = p.client.post(ctx, "v1", fmt.Sprintf("/users/%s/playlists", userID), query, bytes.NewReader(data), playlist)
 
 	return playlist, err
 }
 
-func (p *PlaylistService) Fetch(id string) (*Playlist, error) {
+func (p *PlaylistService) Fetch(ctx context.Context, id string) (*Playlist, error) {
+	return p.FetchFields(ctx, id, "")
+}
+
+// FetchFields is like Fetch but only returns the given fields, e.g.
+// "name,owner(id)". An empty fields returns the whole playlist, including
+// its first page of items.
+func (p *PlaylistService) FetchFields(ctx context.Context, id, fields string) (*Playlist, error) {
+	var query url.Values
+	if fields != "" {
+		query = url.Values{"fields": {fields}}
+	}
+
 	playlist := new(Playlist)
-	err := p.client.get("v1", fmt.Sprintf("/playlists/%s", id), nil, playlist)
+	err := p.client.get(ctx, "v1", fmt.Sprintf("/playlists/%s", id), query, playlist)
 	return playlist, err
 }
 
-func (p *PlaylistService) Update(id, name, description string, public, collaborative bool) (*Playlist, error) {
-	body := &struct {
-		Name          string `json:"name"`
-		Public        bool   `json:"public"`
-		Collaborative bool   `json:"collaborative"`
-		Description   string `json:"description"`
-	}{
-		Name:          name,
-		Public:        public,
-		Collaborative: collaborative,
-		Description:   description,
+// PlaylistUpdate holds the playlist details to change. Nil fields are left
+// unchanged.
+type PlaylistUpdate struct {
+	Name          *string `json:"name,omitempty"`
+	Description   *string `json:"description,omitempty"`
+	Public        *bool   `json:"public,omitempty"`
+	Collaborative *bool   `json:"collaborative,omitempty"`
+}
+
+// String returns a pointer to s, for PlaylistUpdate fields.
+func String(s string) *string {
+	return &s
+}
+
+// Bool returns a pointer to b, for PlaylistUpdate fields.
+func Bool(b bool) *bool {
+	return &b
+}
+
+func (u *PlaylistUpdate) validate() error {
+	if u == nil {
+		return errors.New("spotify: nil playlist update")
+	}
+	if u.Name == nil && u.Description == nil && u.Public == nil && u.Collaborative == nil {
+		return errors.New("spotify: playlist update changes nothing")
 	}
+	if u.Name != nil && *u.Name == "" {
+		return errors.New("spotify: playlist name can't be empty")
+	}
+	// Spotify only allows collaborative playlists that are private, and the
+	// current visibility isn't known here, so it has to be set explicitly.
+	if u.Collaborative != nil && *u.Collaborative && (u.Public == nil || *u.Public) {
+		return errors.New("spotify: collaborative playlists must be private; set Public to false")
+	}
+	return nil
+}
 
-	data, err := json.Marshal(body)
+// Update changes the given details of a playlist and returns the updated
+// playlist.
+func (p *PlaylistService) Update(ctx context.Context, id string, update *PlaylistUpdate) (*Playlist, error) {
+	if err := update.validate(); err != nil {
+		return nil, err
+	}
+	if err := p.client.requireAnyScope(ctx, ScopePlaylistModifyPublic, ScopePlaylistModifyPrivate); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(update)
 	if err != nil {
 		return nil, err
 	}
-	playlist := new(Playlist)
-	err = p.client.put("v1", fmt.Sprintf("/playlists/%s", id), nil, bytes.NewReader(data))
-	return playlist, err
+	if err := p.client.put(ctx, "v1", fmt.Sprintf("/playlists/%s", id), nil, bytes.NewReader(data), nil); err != nil {
+		return nil, err
+	}
+
+	// The endpoint doesn't return the playlist.
+	return p.Fetch(ctx, id)
+}
+
+// Follow adds a playlist to the current user's library. public controls
+// whether it shows up on their profile.
+func (p *PlaylistService) Follow(ctx context.Context, id string, public bool) error {
+	scope := ScopePlaylistModifyPrivate
+	if public {
+		scope = ScopePlaylistModifyPublic
+	}
+	if err := p.client.requireScopes(ctx, scope); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&struct {
+		Public bool `json:"public"`
+	}{
+		Public: public,
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.client.put(ctx, "v1", fmt.Sprintf("/playlists/%s/followers", id), nil, bytes.NewReader(data), nil)
+}
+
+// Unfollow removes a playlist from the current user's library. For a
+// playlist the user owns, this is how it is deleted.
+func (p *PlaylistService) Unfollow(ctx context.Context, id string) error {
+	if err := p.client.requireAnyScope(ctx, ScopePlaylistModifyPublic, ScopePlaylistModifyPrivate); err != nil {
+		return err
+	}
+
+	return p.client.delete(ctx, "v1", fmt.Sprintf("/playlists/%s/followers", id), nil, nil, nil)
+}
+
+// maxFollowerChecks is the most user IDs the follower check accepts at once.
+const maxFollowerChecks = 5
+
+// FollowersContain reports, for each of userIDs, whether that user follows
+// the playlist.
+func (p *PlaylistService) FollowersContain(ctx context.Context, id string, userIDs []string) ([]bool, error) {
+	follows := make([]bool, 0, len(userIDs))
+	for start := 0; start < len(userIDs); start += maxFollowerChecks {
+		end := start + maxFollowerChecks
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+
+		var res []bool
+		query := url.Values{"ids": {strings.Join(userIDs[start:end], ",")}}
+		if err := p.client.get(ctx, "v1", fmt.Sprintf("/playlists/%s/followers/contains", id), query, &res); err != nil {
+			return nil, err
+		}
+		follows = append(follows, res...)
+	}
+
+	return follows, nil
 }
@@ -0,0 +1,95 @@
+package spotifyclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy controls how the client reacts to 429 Too Many Requests.
+type RateLimitPolicy struct {
+	// MaxRetries is how many times a rate-limited request is retried.
+	// Zero disables retries and returns the 429 as an APIError.
+	MaxRetries int
+	// MaxWait caps a single wait. A Retry-After longer than MaxWait is not
+	// waited for and the 429 is returned instead, though later requests are
+	// still held back until it passes. Zero means no cap.
+	MaxWait time.Duration
+	// DefaultWait is used when the response has no usable Retry-After header.
+	DefaultWait time.Duration
+}
+
+// DefaultRateLimitPolicy is used by clients that don't set their own policy.
+var DefaultRateLimitPolicy = RateLimitPolicy{
+	MaxRetries:  3,
+	MaxWait:     time.Minute,
+	DefaultWait: time.Second,
+}
+
+// rateLimiter holds back every request made through a client while Spotify
+// has asked it to slow down, so concurrent callers don't keep hitting 429s.
+type rateLimiter struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// wait blocks until the client is allowed to send requests again.
+func (r *rateLimiter) wait(ctx context.Context) error {
+	r.mu.Lock()
+	d := time.Until(r.until)
+	r.mu.Unlock()
+
+	return sleep(ctx, d)
+}
+
+// block holds back requests for d from now.
+func (r *rateLimiter) block(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(r.until) {
+		r.until = until
+	}
+}
+
+// retryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,108 @@
+package spotifyclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how the client retries transient failures such as
+// 5xx responses and dropped connections. Rate limiting is handled separately
+// by RateLimitPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the exponential backoff between attempts.
+	// The actual wait is picked at random up to the current backoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// StatusCodes lists the response statuses that are retried.
+	StatusCodes []int
+	// Methods lists the HTTP methods that are safe to replay. Requests with
+	// other methods, such as a POST adding tracks to a playlist, are never
+	// retried because the first attempt may already have been applied.
+	// Neither are requests the client knows not to be idempotent whatever
+	// their method, such as removing playlist items by position.
+	Methods []string
+}
+
+// DefaultRetryPolicy is used by clients that don't set their own policy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  250 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+	StatusCodes: []int{
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+	Methods: []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodPut,
+		http.MethodDelete,
+	},
+}
+
+// canRetry reports whether another attempt is allowed after the given number
+// of failed attempts.
+func (p *RetryPolicy) canRetry(method string, idempotent bool, attempts int) bool {
+	if !idempotent || attempts >= p.MaxAttempts {
+		return false
+	}
+
+	for _, m := range p.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) retryStatus(code int) bool {
+	for _, c := range p.StatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the next attempt, using exponential
+// backoff with full jitter.
+func (p *RetryPolicy) backoff(attempts int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempts && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(d)) + 1)
+}
+
+// isTransient reports whether a transport error is worth retrying.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,111 @@
+package spotifyclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		failures  int
+		call      func(c *Client) error
+		wantErr   bool
+		wantCalls int32
+	}{
+		{
+			name:      "get",
+			failures:  2,
+			call:      func(c *Client) error { return c.http.get(ctx, "v1", "/me", nil, nil) },
+			wantCalls: 3,
+		},
+		{
+			name:      "get gives up",
+			failures:  3,
+			call:      func(c *Client) error { return c.http.get(ctx, "v1", "/me", nil, nil) },
+			wantErr:   true,
+			wantCalls: 3,
+		},
+		{
+			name:      "post",
+			failures:  1,
+			call:      func(c *Client) error { return c.http.post(ctx, "v1", "/me", nil, nil, nil) },
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:     "reorder",
+			failures: 1,
+			call: func(c *Client) error {
+				_, err := c.Playlist.ReorderItems(ctx, "p", 0, 2, 1, "snap")
+				return err
+			},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:     "remove by position",
+			failures: 1,
+			call: func(c *Client) error {
+				_, err := c.Playlist.RemoveItems(ctx, "p", []PlaylistItem{{URI: "spotify:track:a", Positions: []int{0}}}, "snap")
+				return err
+			},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:     "remove every occurrence",
+			failures: 1,
+			call: func(c *Client) error {
+				_, err := c.Playlist.RemoveItems(ctx, "p", []PlaylistItem{{URI: "spotify:track:a"}}, "")
+				return err
+			},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			c := newTestClient(t, failFirst(tt.failures, http.StatusBadGateway, nil, `{"snapshot_id":"new"}`, &calls))
+
+			err := tt.call(c)
+			if tt.wantErr {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+					t.Errorf("err = %v, want a 502 APIError", err)
+				}
+			} else if err != nil {
+				t.Errorf("err = %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("%d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryDroppedConnection(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+
+	if err := c.http.get(context.Background(), "v1", "/me", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("%d requests, want 2", calls)
+	}
+}
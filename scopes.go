@@ -0,0 +1,202 @@
+package spotifyclient
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Scope is an OAuth scope granting access to part of the Spotify Web API.
+// https://developer.spotify.com/documentation/web-api/concepts/scopes
+type Scope string
+
+// Images
+const (
+	ScopeUGCImageUpload Scope = "ugc-image-upload"
+)
+
+// Spotify Connect
+const (
+	ScopeUserReadPlaybackState    Scope = "user-read-playback-state"
+	ScopeUserModifyPlaybackState  Scope = "user-modify-playback-state"
+	ScopeUserReadCurrentlyPlaying Scope = "user-read-currently-playing"
+)
+
+// Playback
+const (
+	ScopeAppRemoteControl Scope = "app-remote-control"
+	ScopeStreaming        Scope = "streaming"
+)
+
+// Playlists
+const (
+	ScopePlaylistReadPrivate       Scope = "playlist-read-private"
+	ScopePlaylistReadCollaborative Scope = "playlist-read-collaborative"
+	ScopePlaylistModifyPrivate     Scope = "playlist-modify-private"
+	ScopePlaylistModifyPublic      Scope = "playlist-modify-public"
+)
+
+// Follow
+const (
+	ScopeUserFollowModify Scope = "user-follow-modify"
+	ScopeUserFollowRead   Scope = "user-follow-read"
+)
+
+// Listening history
+const (
+	ScopeUserReadPlaybackPosition Scope = "user-read-playback-position"
+	ScopeUserTopRead              Scope = "user-top-read"
+	ScopeUserReadRecentlyPlayed   Scope = "user-read-recently-played"
+)
+
+// Library
+const (
+	ScopeUserLibraryModify Scope = "user-library-modify"
+	ScopeUserLibraryRead   Scope = "user-library-read"
+)
+
+// Users
+const (
+	ScopeUserReadEmail    Scope = "user-read-email"
+	ScopeUserReadPrivate  Scope = "user-read-private"
+	ScopeUserPersonalized Scope = "user-personalized"
+)
+
+// Open Access
+const (
+	ScopeUserSOALink           Scope = "user-soa-link"
+	ScopeUserSOAUnlink         Scope = "user-soa-unlink"
+	ScopeSOAManageEntitlements Scope = "soa-manage-entitlements"
+	ScopeSOAManagePartner      Scope = "soa-manage-partner"
+	ScopeSOACreatePartner      Scope = "soa-create-partner"
+)
+
+// ScopeSet is a set of scopes.
+type ScopeSet map[Scope]struct{}
+
+// NewScopeSet returns a set holding the given scopes, without duplicates.
+func NewScopeSet(scopes ...Scope) ScopeSet {
+	set := make(ScopeSet, len(scopes))
+	for _, s := range scopes {
+		if s != "" {
+			set[s] = struct{}{}
+		}
+	}
+	return set
+}
+
+// ParseScopes parses a space-separated scope list, as found in Token.Scope.
+func ParseScopes(s string) ScopeSet {
+	fields := strings.Fields(s)
+	scopes := make([]Scope, len(fields))
+	for i, f := range fields {
+		scopes[i] = Scope(f)
+	}
+	return NewScopeSet(scopes...)
+}
+
+// Has reports whether the set holds every given scope.
+func (s ScopeSet) Has(scopes ...Scope) bool {
+	return len(s.Missing(scopes...)) == 0
+}
+
+// Missing returns the given scopes that are not in the set.
+func (s ScopeSet) Missing(scopes ...Scope) []Scope {
+	var missing []Scope
+	for _, scope := range scopes {
+		if _, ok := s[scope]; !ok {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// Slice returns the scopes in the set, sorted.
+func (s ScopeSet) Slice() []Scope {
+	scopes := make([]Scope, 0, len(s))
+	for scope := range s {
+		scopes = append(scopes, scope)
+	}
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i] < scopes[j] })
+	return scopes
+}
+
+// String formats the set as a sorted, space-separated scope list.
+func (s ScopeSet) String() string {
+	return joinScopes(s.Slice())
+}
+
+func joinScopes(scopes []Scope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Scopes returns the scopes granted to the token.
+func (t *Token) Scopes() ScopeSet {
+	return ParseScopes(t.Scope)
+}
+
+// MissingScopeError is returned before calling an endpoint the token isn't
+// authorized for.
+type MissingScopeError struct {
+	// Missing lists the scopes the token lacks.
+	Missing []Scope
+	// AnyOf is set when any one of the missing scopes would be enough.
+	AnyOf bool
+}
+
+func (e *MissingScopeError) Error() string {
+	if e.AnyOf {
+		return "spotify: token needs one of the scopes: " + joinScopes(e.Missing)
+	}
+	return "spotify: token is missing required scopes: " + joinScopes(e.Missing)
+}
+
+// grantedScopes returns the scopes of the current token. ok is false when the
+// client doesn't know them, e.g. for a bare access token, in which case the
+// API is left to enforce them.
+func (h *httpClient) grantedScopes(ctx context.Context) (scopes ScopeSet, ok bool, err error) {
+	if err := h.requireUser(); err != nil {
+		return nil, false, err
+	}
+
+	token, err := h.tokens.Token(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if token.Scope == "" {
+		return nil, false, nil
+	}
+	return token.Scopes(), true, nil
+}
+
+// requireScopes fails when the client acts without a user or its token lacks
+// any of the given scopes.
+func (h *httpClient) requireScopes(ctx context.Context, scopes ...Scope) error {
+	granted, ok, err := h.grantedScopes(ctx)
+	if err != nil || !ok {
+		return err
+	}
+
+	if missing := granted.Missing(scopes...); len(missing) > 0 {
+		return &MissingScopeError{Missing: missing}
+	}
+	return nil
+}
+
+// requireAnyScope fails when the client acts without a user or its token has
+// none of the given scopes.
+func (h *httpClient) requireAnyScope(ctx context.Context, scopes ...Scope) error {
+	granted, ok, err := h.grantedScopes(ctx)
+	if err != nil || !ok {
+		return err
+	}
+
+	if missing := granted.Missing(scopes...); len(scopes) > 0 && len(missing) == len(scopes) {
+		return &MissingScopeError{Missing: missing, AnyOf: true}
+	}
+	return nil
+}
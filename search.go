@@ -0,0 +1,54 @@
+package spotifyclient
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// SearchService provides access to the Spotify Web API's search endpoint.
+type SearchService service
+
+// SearchOptions configures a search.
+type SearchOptions struct {
+	// Market is an ISO 3166-1 alpha-2 country code; only content playable
+	// there is returned.
+	Market string
+	// Limit is the page size. Zero uses the maximum of 50.
+	Limit int
+}
+
+// Tracks returns an iterator over the tracks matching query, which may use
+// field filters such as `track:"title" artist:"name"` or `isrc:USUM71703861`.
+func (s *SearchService) Tracks(query string, opts *SearchOptions) *Iterator[*Track] {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+
+	q := url.Values{"q": {query}, "type": {"track"}}
+	limit := opts.Limit
+	if limit <= 0 || limit > 50 {
+		limit = 50
+	}
+	q.Set("limit", strconv.Itoa(limit))
+	if opts.Market != "" {
+		q.Set("market", opts.Market)
+	}
+
+	// Every page comes wrapped as {"tracks": {...}}.
+	type result struct {
+		Tracks TrackPage `json:"tracks"`
+	}
+	return newWrappedOffsetIterator(
+		func(ctx context.Context) (*TrackPage, error) {
+			res := new(result)
+			err := s.client.get(ctx, "v1", "/search", q, res)
+			return &res.Tracks, err
+		},
+		func(ctx context.Context, href HREF) (*TrackPage, error) {
+			res := new(result)
+			err := href.get(ctx, s.client, res)
+			return &res.Tracks, err
+		},
+	)
+}
@@ -0,0 +1,239 @@
+package spotifyclient
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	secure "crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// ErrTokenNotFound is returned by a TokenStore that holds no token.
+var ErrTokenNotFound = errors.New("spotify: no stored token")
+
+// TokenStore persists tokens between runs.
+type TokenStore interface {
+	// Load returns the stored token, or ErrTokenNotFound.
+	Load() (*Token, error)
+	// Save replaces the stored token.
+	Save(tok *Token) error
+	// Delete removes the stored token. Deleting a missing token is not an error.
+	Delete() error
+}
+
+// MemoryTokenStore keeps a token in memory. It is safe for concurrent use.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token *Token
+}
+
+func (m *MemoryTokenStore) Load() (*Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.token == nil {
+		return nil, ErrTokenNotFound
+	}
+	tok := *m.token
+	return &tok, nil
+}
+
+func (m *MemoryTokenStore) Save(tok *Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := *tok
+	m.token = &saved
+	return nil
+}
+
+func (m *MemoryTokenStore) Delete() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.token = nil
+	return nil
+}
+
+// FileTokenStore stores a token as JSON in a file only readable by the
+// current user. Writes are atomic.
+type FileTokenStore struct {
+	Path string
+}
+
+func (f *FileTokenStore) Load() (*Token, error) {
+	data, err := readTokenFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	tok := new(Token)
+	if err := json.Unmarshal(data, tok); err != nil {
+		return nil, fmt.Errorf("spotify: decode token file: %w", err)
+	}
+	return tok, nil
+}
+
+func (f *FileTokenStore) Save(tok *Token) error {
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	return writeTokenFile(f.Path, data)
+}
+
+func (f *FileTokenStore) Delete() error {
+	return deleteTokenFile(f.Path)
+}
+
+// Key derivation parameters for EncryptedFileTokenStore.
+const (
+	keyIterations = 600000
+	keySize       = 32
+	saltSize      = 16
+)
+
+// EncryptedFileTokenStore stores a token in a file encrypted with AES-GCM,
+// using a key derived from a passphrase with PBKDF2-SHA256.
+type EncryptedFileTokenStore struct {
+	Path       string
+	Passphrase string
+}
+
+// encryptedToken is the on-disk format of EncryptedFileTokenStore.
+type encryptedToken struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (e *EncryptedFileTokenStore) Load() (*Token, error) {
+	data, err := readTokenFile(e.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := new(encryptedToken)
+	if err := json.Unmarshal(data, enc); err != nil {
+		return nil, fmt.Errorf("spotify: decode token file: %w", err)
+	}
+	if enc.Version != 1 {
+		return nil, fmt.Errorf("spotify: unsupported token file version %d", enc.Version)
+	}
+
+	gcm, err := e.cipher(enc.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, enc.Nonce, enc.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("spotify: cannot decrypt token file: wrong passphrase or corrupted file")
+	}
+
+	tok := new(Token)
+	if err := json.Unmarshal(plain, tok); err != nil {
+		return nil, fmt.Errorf("spotify: decode token: %w", err)
+	}
+	return tok, nil
+}
+
+func (e *EncryptedFileTokenStore) Save(tok *Token) error {
+	plain, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+
+	enc := &encryptedToken{Version: 1, Salt: make([]byte, saltSize)}
+	if _, err := io.ReadFull(secure.Reader, enc.Salt); err != nil {
+		return err
+	}
+	gcm, err := e.cipher(enc.Salt)
+	if err != nil {
+		return err
+	}
+	enc.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(secure.Reader, enc.Nonce); err != nil {
+		return err
+	}
+	enc.Ciphertext = gcm.Seal(nil, enc.Nonce, plain, nil)
+
+	data, err := json.Marshal(enc)
+	if err != nil {
+		return err
+	}
+	return writeTokenFile(e.Path, data)
+}
+
+func (e *EncryptedFileTokenStore) Delete() error {
+	return deleteTokenFile(e.Path)
+}
+
+func (e *EncryptedFileTokenStore) cipher(salt []byte) (cipher.AEAD, error) {
+	if e.Passphrase == "" {
+		return nil, errors.New("spotify: empty token store passphrase")
+	}
+
+	key := pbkdf2.Key([]byte(e.Passphrase), salt, keyIterations, keySize, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func readTokenFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrTokenNotFound
+	}
+	return data, err
+}
+
+// writeTokenFile writes data to a temporary file next to path and renames it
+// into place, so readers never see a partially written token.
+func writeTokenFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func deleteTokenFile(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
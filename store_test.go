@@ -0,0 +1,34 @@
+package spotifyclient
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedFileTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	store := &EncryptedFileTokenStore{Path: path, Passphrase: "secret"}
+
+	if _, err := store.Load(); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("Load of missing file: err = %v, want ErrTokenNotFound", err)
+	}
+
+	tok := &Token{AccessToken: "access", RefreshToken: "refresh", Scope: "user-read-private"}
+	if err := store.Save(tok); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AccessToken != tok.AccessToken || got.RefreshToken != tok.RefreshToken || got.Scope != tok.Scope {
+		t.Errorf("Load = %+v, want %+v", got, tok)
+	}
+
+	wrong := &EncryptedFileTokenStore{Path: path, Passphrase: "wrong"}
+	if _, err := wrong.Load(); err == nil {
+		t.Error("Load with the wrong passphrase succeeded")
+	}
+}
@@ -0,0 +1,274 @@
+package spotifyclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshLeeway is how long before expiry a RefreshingTokenSource
+// refreshes its token.
+const DefaultRefreshLeeway = time.Minute
+
+// ErrNoRefreshToken is returned when a token has to be refreshed but has no
+// refresh token. It matches ErrLoginRequired.
+var ErrNoRefreshToken = fmt.Errorf("spotify: token has no refresh token: %w", ErrLoginRequired)
+
+// TokenSource supplies the token used to authorize each request.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+type staticTokenSource struct {
+	token *Token
+}
+
+// StaticTokenSource returns a TokenSource that always returns tok.
+func StaticTokenSource(tok *Token) TokenSource {
+	return &staticTokenSource{token: tok}
+}
+
+func (s *staticTokenSource) Token(ctx context.Context) (*Token, error) {
+	return s.token, nil
+}
+
+// RefreshFunc exchanges a refresh token for a new token.
+type RefreshFunc func(ctx context.Context, refreshToken string) (*Token, error)
+
+// PKCERefresher returns a RefreshFunc using RefreshPKCEToken.
+func PKCERefresher(clientID string) RefreshFunc {
+	return func(ctx context.Context, refreshToken string) (*Token, error) {
+		return RefreshPKCEToken(ctx, refreshToken, clientID)
+	}
+}
+
+// Refresher returns a RefreshFunc using RefreshToken.
+func Refresher(clientID, clientSecret string) RefreshFunc {
+	return func(ctx context.Context, refreshToken string) (*Token, error) {
+		return RefreshToken(ctx, refreshToken, clientID, clientSecret)
+	}
+}
+
+// RefreshingTokenSource is a TokenSource that refreshes its token shortly
+// before it expires. It is safe for concurrent use.
+type RefreshingTokenSource struct {
+	// Leeway is how long before expiry the token is refreshed.
+	Leeway time.Duration
+	// Store, if set, supplies the initial token when none was given and
+	// receives every refreshed token, so rotated refresh tokens survive
+	// restarts.
+	Store TokenStore
+
+	mu      sync.Mutex
+	token   *Token
+	refresh RefreshFunc
+	// loginErr is the refresh error that requires a new login. Once set, it
+	// is returned without contacting the accounts service again.
+	loginErr error
+}
+
+// NewRefreshingTokenSource returns a RefreshingTokenSource starting from tok
+// and using refresh to obtain new tokens.
+func NewRefreshingTokenSource(tok *Token, refresh RefreshFunc) *RefreshingTokenSource {
+	return &RefreshingTokenSource{
+		Leeway:  DefaultRefreshLeeway,
+		token:   tok,
+		refresh: refresh,
+	}
+}
+
+// Token returns the current token, refreshing it first if it is about to
+// expire.
+func (s *RefreshingTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == nil && s.Store != nil {
+		tok, err := s.Store.Load()
+		if err != nil {
+			return nil, err
+		}
+		s.token = tok
+	}
+
+	if s.token != nil && s.token.AccessToken != "" && !s.token.expiresWithin(s.Leeway) {
+		return s.token, nil
+	}
+
+	return s.refreshLocked(ctx)
+}
+
+// SetToken replaces the token, e.g. after the user logged in again following
+// an ErrLoginRequired error. The token is saved to Store if one is set.
+func (s *RefreshingTokenSource) SetToken(tok *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = tok
+	s.loginErr = nil
+
+	if s.Store != nil {
+		return s.Store.Save(tok)
+	}
+	return nil
+}
+
+// attachStore sets Store and saves the current token to it.
+func (s *RefreshingTokenSource) attachStore(store TokenStore) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Store = store
+	if s.token == nil {
+		return nil
+	}
+	return store.Save(s.token)
+}
+
+// Refresh unconditionally refreshes the token.
+func (s *RefreshingTokenSource) Refresh(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.refreshLocked(ctx)
+}
+
+// refreshStale refreshes the token after the API rejected accessToken,
+// unless another caller already replaced it.
+func (s *RefreshingTokenSource) refreshStale(ctx context.Context, accessToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.token.AccessToken != accessToken {
+		return nil
+	}
+
+	_, err := s.refreshLocked(ctx)
+	return err
+}
+
+func (s *RefreshingTokenSource) refreshLocked(ctx context.Context) (*Token, error) {
+	if s.loginErr != nil {
+		return nil, s.loginErr
+	}
+	if s.token == nil || s.token.RefreshToken == "" {
+		return nil, ErrNoRefreshToken
+	}
+
+	tok, err := s.refresh(ctx, s.token.RefreshToken)
+	if err != nil {
+		// A revoked refresh token won't start working again; stop retrying.
+		if errors.Is(err, ErrLoginRequired) {
+			s.loginErr = err
+		}
+		return nil, err
+	}
+
+	// Spotify only sometimes rotates the refresh token; keep the old one
+	// when the response doesn't carry a new one.
+	if tok.RefreshToken == "" {
+		tok.RefreshToken = s.token.RefreshToken
+	}
+	s.token = tok
+
+	if s.Store != nil {
+		if err := s.Store.Save(tok); err != nil {
+			return nil, fmt.Errorf("spotify: save refreshed token: %w", err)
+		}
+	}
+
+	return tok, nil
+}
+
+// ClientCredentialsTokenSource is a TokenSource for app-only access. It
+// requests a new client credentials token whenever the current one is about
+// to expire. It is safe for concurrent use.
+type ClientCredentialsTokenSource struct {
+	// Leeway is how long before expiry a new token is requested.
+	Leeway time.Duration
+
+	mu      sync.Mutex
+	token   *Token
+	request func(ctx context.Context) (*Token, error)
+
+	clientID, clientSecret string
+}
+
+// NewClientCredentialsTokenSource returns a ClientCredentialsTokenSource for
+// the given application credentials. Tokens are requested with
+// http.DefaultClient from the production accounts service until the source is
+// passed to WithTokenSource, which makes it use the client's HTTP client and
+// accounts URL instead.
+func NewClientCredentialsTokenSource(clientID, clientSecret string) *ClientCredentialsTokenSource {
+	s := newClientCredentialsTokenSource(func(ctx context.Context) (*Token, error) {
+		return RequestClientCredentialsToken(ctx, clientID, clientSecret)
+	})
+	s.clientID, s.clientSecret = clientID, clientSecret
+	return s
+}
+
+func newClientCredentialsTokenSource(request func(ctx context.Context) (*Token, error)) *ClientCredentialsTokenSource {
+	return &ClientCredentialsTokenSource{
+		Leeway:  DefaultRefreshLeeway,
+		request: request,
+	}
+}
+
+// Token returns the current token, requesting a new one first if it is about
+// to expire.
+func (s *ClientCredentialsTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && !s.token.expiresWithin(s.Leeway) {
+		return s.token, nil
+	}
+
+	return s.requestLocked(ctx)
+}
+
+func (s *ClientCredentialsTokenSource) refreshStale(ctx context.Context, accessToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.token.AccessToken != accessToken {
+		return nil
+	}
+
+	_, err := s.requestLocked(ctx)
+	return err
+}
+
+// use makes s request its tokens with client from accountsURL. It does
+// nothing for sources without credentials of their own.
+func (s *ClientCredentialsTokenSource) use(client *http.Client, accountsURL string) {
+	if s.clientID == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.request = func(ctx context.Context) (*Token, error) {
+		return requestClientCredentials(ctx, client, accountsURL, s.clientID, s.clientSecret)
+	}
+}
+
+func (s *ClientCredentialsTokenSource) requestLocked(ctx context.Context) (*Token, error) {
+	tok, err := s.request(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.token = tok
+
+	return tok, nil
+}
+
+// staleRefresher is implemented by token sources that can recover from a
+// 401 by refreshing the rejected token.
+type staleRefresher interface {
+	refreshStale(ctx context.Context, accessToken string) error
+}
@@ -2,6 +2,7 @@ package spotifyclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/url"
 )
@@ -9,40 +10,95 @@ import (
 // UserService provides access to the Spotify Web API's user endpoints.
 type UserService service
 
-func (u *UserService) Me() (*Me, error) {
+func (u *UserService) Me(ctx context.Context) (*Me, error) {
+	if err := u.client.requireUser(); err != nil {
+		return nil, err
+	}
+
 	Me := &Me{}
-	err := u.client.get("v1", "/me", nil, Me)
+	err := u.client.get(ctx, "v1", "/me", nil, Me)
 	return Me, err
 }
 
-func (u *UserService) Devices() (*Devices, error) {
+func (u *UserService) Devices(ctx context.Context) (*Devices, error) {
+	if err := u.client.requireScopes(ctx, ScopeUserReadPlaybackState); err != nil {
+		return nil, err
+	}
+
 	Devices := &Devices{}
-	err := u.client.get("v1", "/me/player/devices", nil, Devices)
+	err := u.client.get(ctx, "v1", "/me/player/devices", nil, Devices)
 	return Devices, err
 }
 
-func (u *UserService) Play(deviceID string, body *SetPlay) error {
+func (u *UserService) Play(ctx context.Context, deviceID string, body *SetPlay) error {
+	if err := u.client.requireScopes(ctx, ScopeUserModifyPlaybackState); err != nil {
+		return err
+	}
+
 	b, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
 
 	reader := bytes.NewReader(b)
-	err = u.client.put("v1", "/me/player/play", url.Values{"device_id": {deviceID}}, reader)
+	err = u.client.put(ctx, "v1", "/me/player/play", url.Values{"device_id": {deviceID}}, reader, nil)
 	return err
 }
 
-func (u *UserService) Next(deviceID string) error {
-	err := u.client.post("v1", "/me/player/next", url.Values{"device_id": {deviceID}}, nil, nil)
+func (u *UserService) Next(ctx context.Context, deviceID string) error {
+	if err := u.client.requireScopes(ctx, ScopeUserModifyPlaybackState); err != nil {
+		return err
+	}
+
+	err := u.client.post(ctx, "v1", "/me/player/next", url.Values{"device_id": {deviceID}}, nil, nil)
 	return err
 }
 
-func (u *UserService) Previous(deviceID string) error {
-	err := u.client.post("v1", "/me/player/previous", url.Values{"device_id": {deviceID}}, nil, nil)
+func (u *UserService) Previous(ctx context.Context, deviceID string) error {
+	if err := u.client.requireScopes(ctx, ScopeUserModifyPlaybackState); err != nil {
+		return err
+	}
+
+	err := u.client.post(ctx, "v1", "/me/player/previous", url.Values{"device_id": {deviceID}}, nil, nil)
 	return err
 }
 
-func (u *UserService) Pause(deviceID string) error {
-	err := u.client.put("v1", "/me/player/pause", url.Values{"device_id": {deviceID}}, nil)
+func (u *UserService) Pause(ctx context.Context, deviceID string) error {
+	if err := u.client.requireScopes(ctx, ScopeUserModifyPlaybackState); err != nil {
+		return err
+	}
+
+	err := u.client.put(ctx, "v1", "/me/player/pause", url.Values{"device_id": {deviceID}}, nil, nil)
 	return err
 }
+
+// FollowedArtists returns an iterator over the artists the current user
+// follows. Only CursorAfter is supported by the API.
+func (u *UserService) FollowedArtists(opts *CursorOptions) *Iterator[*Artist] {
+	return newCursorIterator(opts, 50, func(ctx context.Context, query url.Values) (*CursorPage[*Artist], error) {
+		if err := u.client.requireScopes(ctx, ScopeUserFollowRead); err != nil {
+			return nil, err
+		}
+
+		query.Set("type", "artist")
+		res := &struct {
+			Artists CursorPage[*Artist] `json:"artists"`
+		}{}
+		err := u.client.get(ctx, "v1", "/me/following", query, res)
+		return &res.Artists, err
+	})
+}
+
+// RecentlyPlayed returns an iterator over the tracks the current user played
+// recently, newest first when walking with CursorBefore.
+func (u *UserService) RecentlyPlayed(opts *CursorOptions) *Iterator[*PlayHistory] {
+	return newCursorIterator(opts, 50, func(ctx context.Context, query url.Values) (*CursorPage[*PlayHistory], error) {
+		if err := u.client.requireScopes(ctx, ScopeUserReadRecentlyPlayed); err != nil {
+			return nil, err
+		}
+
+		page := new(CursorPage[*PlayHistory])
+		err := u.client.get(ctx, "v1", "/me/player/recently-played", query, page)
+		return page, err
+	})
+}
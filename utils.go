@@ -1,67 +1,88 @@
 package spotifyclient
 
 import (
+	"context"
 	secure "crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
-	"encoding/binary"
-	"math/rand"
+	"encoding/json"
+	"fmt"
 	"net/url"
 	"strings"
 	"time"
 )
 
+// Duration is a duration the API reports in milliseconds.
 type Duration struct {
 	time.Duration
 }
 
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var ms int64
+	if err := json.Unmarshal(data, &ms); err != nil {
+		return err
+	}
+	d.Duration = time.Duration(ms) * time.Millisecond
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Milliseconds())
+}
+
 type HREF string
 
-func (h *HREF) Get(c *httpClient, obj interface{}) error {
+// Get fetches the API object h points to into obj. Only the path and query
+// of h are used, so the request goes to the client's base URL.
+func (h *HREF) Get(ctx context.Context, c *Client, obj interface{}) error {
+	return h.get(ctx, c.http, obj)
+}
+
+func (h *HREF) get(ctx context.Context, c *httpClient, obj interface{}) error {
 	url, err := h.URL()
 	if err != nil {
 		return err
 	}
 
-	idx := strings.Index(url.Path, "/")
-	version := url.Path[:idx]
-	endpoint := url.Path[idx:]
+	// The path looks like /v1/playlists/{id}/tracks.
+	p := strings.TrimPrefix(url.Path, "/")
+	idx := strings.Index(p, "/")
+	if idx < 0 {
+		return fmt.Errorf("spotify: unexpected href %q", string(*h))
+	}
+	version := p[:idx]
+	endpoint := p[idx:]
 
-	// return c.Get(version, endpoint, url.Query(), obj)
-	return c.get(version, endpoint, url.Query(), obj)
+	return c.get(ctx, version, endpoint, url.Query(), obj)
 }
 
 func (h *HREF) URL() (*url.URL, error) {
 	return url.Parse(string(*h))
 }
 
-func (im *PagingMeta) Get(c *httpClient, obj interface{}) error {
-	return im.HREF.Get(c, obj)
+// Get fetches the API object im.HREF points to into obj, see HREF.Get.
+func (im *PagingMeta) Get(ctx context.Context, c *Client, obj interface{}) error {
+	return im.HREF.Get(ctx, c, obj)
 }
 
+// generateRandomVerifier returns a PKCE code verifier drawn from
+// crypto/rand, as required by RFC 7636.
 func generateRandomVerifier() ([]byte, error) {
-	seed, err := func() (int64, error) {
-		buf := make([]byte, 8)
-		_, err := secure.Read(buf)
-		if err != nil {
-			return 0, err
-		}
-
-		seed := int64(binary.BigEndian.Uint64(buf))
-		return seed, nil
-	}()
-
-	if err != nil {
-		return nil, err
-	}
-	rand.Seed(seed)
-
 	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_.-~"
+	// Bytes at or above this bound are skipped to avoid modulo bias.
+	const bound = 256 - 256%len(chars)
 
-	verifier := make([]byte, 128)
-	for i := 0; i < len(verifier); i++ {
-		idx := rand.Intn(len(chars))
-		verifier[i] = chars[idx]
+	verifier := make([]byte, 0, 128)
+	buf := make([]byte, 64)
+	for len(verifier) < cap(verifier) {
+		if _, err := secure.Read(buf); err != nil {
+			return nil, err
+		}
+		for _, b := range buf {
+			if int(b) < bound && len(verifier) < cap(verifier) {
+				verifier = append(verifier, chars[int(b)%len(chars)])
+			}
+		}
 	}
 
 	return verifier, nil